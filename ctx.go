@@ -0,0 +1,225 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package tgbotapi
+
+import (
+	"errors"
+
+	"github.com/qioalice/devola/core/chat"
+
+	"github.com/qioalice/devola-backend-telegram/api"
+	"github.com/qioalice/devola-backend-telegram/ikba"
+)
+
+// Errors that can be returned by Ctx's methods.
+var (
+
+	// ErrNoChat means that update has no chat (for example it's an inline
+	// query) and a message can't be sent.
+	ErrNoChat = errors.New("tgbotapi: update has no chat")
+
+	// ErrNoMessage means that update is not a pressed inline keyboard button
+	// attached to the message sent by bot and there is no message to edit.
+	ErrNoMessage = errors.New("tgbotapi: update has no message to edit")
+
+	// ErrNoCallbackQuery means that update is not a callback query and there
+	// is nothing to answer.
+	ErrNoCallbackQuery = errors.New("tgbotapi: update is not a callback query")
+)
+
+// Ctx is a context of one Telegram Bot API update handling.
+//
+// Ctx is created by Router for each update and passed to the Handler.
+// It contains everything handler may need: BotAPI object, the original
+// update, the event update classified as, the chat and the user that
+// caused update, and the decoded IKB action.
+// Also it has helpers to reply to the update.
+//
+// More info: Handler, Router, Event.
+type Ctx struct {
+
+	// BotAPI object the update has been received by.
+	Bot *api.BotAPI
+
+	// The original update.
+	Update api.Update
+
+	// The event update classified as.
+	Event *Event
+
+	// The chat update has been received from.
+	// Nil if update has no chat (inline query, shipping query, etc).
+	Chat *api.Chat
+
+	// The user that caused update.
+	// Nil if update has no user (channel post).
+	User *api.User
+
+	// Decoded IKB action.
+	// Not nil only if Event.Type == CTypeInlineKeyboardButton.
+	IKBA *ikba.Encoded
+
+	// Session ID the update is linked with.
+	// If router knows the current session of chats (see Router.WithSessions),
+	// it's the current session ID of the chat.
+	// Otherwise for pressed inline keyboard buttons it's the session ID
+	// encoded into the button, and chat.CSessionIDNil for anything else.
+	SessionID chat.SessionID
+
+	// Registry of active reply keyboards (may be nil).
+	// Reply keyboards sent by Reply are tracked by it.
+	keyboards *KeyboardRegistry
+}
+
+// newCtx creates a new Ctx object for update classified as event e
+// and received by bot.
+func newCtx(bot *api.BotAPI, update api.Update, e *Event, keyboards *KeyboardRegistry) *Ctx {
+
+	ctx := &Ctx{
+		Bot:       bot,
+		Update:    update,
+		Event:     e,
+		IKBA:      e.ikbae,
+		SessionID: chat.CSessionIDNil,
+		keyboards: keyboards,
+	}
+
+	if ctx.IKBA != nil {
+		ctx.SessionID = ctx.IKBA.GetSessionID()
+	}
+
+	switch {
+
+	case update.Message != nil:
+		ctx.Chat, ctx.User = update.Message.Chat, update.Message.From
+
+	case update.EditedMessage != nil:
+		ctx.Chat, ctx.User = update.EditedMessage.Chat, update.EditedMessage.From
+
+	case update.ChannelPost != nil:
+		ctx.Chat, ctx.User = update.ChannelPost.Chat, update.ChannelPost.From
+
+	case update.EditedChannelPost != nil:
+		ctx.Chat, ctx.User = update.EditedChannelPost.Chat, update.EditedChannelPost.From
+
+	case update.CallbackQuery != nil:
+		ctx.User = update.CallbackQuery.From
+		if update.CallbackQuery.Message != nil {
+			ctx.Chat = update.CallbackQuery.Message.Chat
+		}
+
+	case update.InlineQuery != nil:
+		ctx.User = update.InlineQuery.From
+
+	case update.ChosenInlineResult != nil:
+		ctx.User = update.ChosenInlineResult.From
+
+	case update.ShippingQuery != nil:
+		ctx.User = update.ShippingQuery.From
+
+	case update.PreCheckoutQuery != nil:
+		ctx.User = update.PreCheckoutQuery.From
+	}
+
+	return ctx
+}
+
+// Reply sends a text message to the chat update has been received from.
+//
+// markup is an optional reply markup (nil, api.InlineKeyboardMarkup,
+// api.ReplyKeyboardMarkup, api.ReplyKeyboardRemove, etc).
+// If router has a registry of active reply keyboards,
+// sent reply keyboard is registered (or unregistered if it's removed).
+func (ctx *Ctx) Reply(text string, markup interface{}) (*api.Message, error) {
+
+	if ctx.Chat == nil {
+		return nil, ErrNoChat
+	}
+
+	cfg := api.NewMessage(ctx.Chat.ID, text)
+	cfg.ReplyMarkup = markup
+
+	msg, err := ctx.Bot.Send(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if ctx.keyboards != nil {
+		ctx.keyboards.Track(ctx.Chat.ID, markup)
+	}
+
+	return msg, nil
+}
+
+// Edit changes the text and the inline keyboard of the message
+// which inline keyboard button has been pressed.
+// If markup is nil, inline keyboard is removed.
+//
+// Only messages sent by bot to the chat can be edited
+// (not messages sent via bot in inline mode).
+func (ctx *Ctx) Edit(text string, markup *api.InlineKeyboardMarkup) (*api.Message, error) {
+
+	cq := ctx.Update.CallbackQuery
+	if cq == nil || cq.Message == nil || cq.Message.Chat == nil {
+		return nil, ErrNoMessage
+	}
+
+	cfg := api.NewEditMessageText(cq.Message.Chat.ID, cq.Message.MessageID, text)
+	cfg.ReplyMarkup = markup
+
+	return ctx.Bot.Send(cfg)
+}
+
+// RemoveKeyboard removes the inline keyboard of the message
+// which inline keyboard button has been pressed.
+func (ctx *Ctx) RemoveKeyboard() error {
+
+	cq := ctx.Update.CallbackQuery
+	if cq == nil || cq.Message == nil || cq.Message.Chat == nil {
+		return ErrNoMessage
+	}
+
+	// Empty (but not nil) keyboard, Telegram rejects null
+	markup := api.InlineKeyboardMarkup{
+		InlineKeyboard: [][]api.InlineKeyboardButton{},
+	}
+
+	_, err := ctx.Bot.Send(api.NewEditMessageReplyMarkup(
+		cq.Message.Chat.ID, cq.Message.MessageID, markup))
+	return err
+}
+
+// AnswerCallback answers to the pressed inline keyboard button.
+// text is an optional notification text, that will be shown as alert
+// if alert is true or at the top of the chat screen otherwise.
+func (ctx *Ctx) AnswerCallback(text string, alert bool) error {
+
+	cq := ctx.Update.CallbackQuery
+	if cq == nil {
+		return ErrNoCallbackQuery
+	}
+
+	cfg := api.NewCallback(cq.ID, text)
+	cfg.ShowAlert = alert
+
+	resp, err := ctx.Bot.AnswerCallbackQuery(cfg)
+	if resp != nil {
+		ctx.Bot.Dealloc(resp.RAW)
+	}
+	return err
+}
+
+// SendChatAction sends the bot's action to the chat update has been
+// received from. Action must be one of api's constants starts from "Chat...".
+func (ctx *Ctx) SendChatAction(action string) error {
+
+	if ctx.Chat == nil {
+		return ErrNoChat
+	}
+
+	_, err := ctx.Bot.SendChatAction(ctx.Chat.ID, action)
+	return err
+}
@@ -0,0 +1,54 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package tgbotapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/qioalice/devola/core/chat"
+
+	"github.com/qioalice/devola-backend-telegram/api"
+	"github.com/qioalice/devola-backend-telegram/ikba"
+)
+
+func TestNewCtxCallbackQuery(t *testing.T) {
+
+	ikbae := ikba.New()
+	ikbae.PutSessionID(chat.SessionID(3))
+
+	user, c := &api.User{ID: 1}, &api.Chat{ID: 2}
+	update := api.Update{CallbackQuery: &api.CallbackQuery{
+		From:    user,
+		Message: &api.Message{Chat: c},
+		Data:    ikbae.CallbackData(),
+	}}
+
+	ctx := newCtx(nil, update, MakeEventFromUpdate(update), nil)
+	require.True(t, user == ctx.User)
+	require.True(t, c == ctx.Chat)
+	require.Equal(t, ikbae, ctx.IKBA)
+	require.Equal(t, chat.SessionID(3), ctx.SessionID)
+}
+
+func TestCtxErrors(t *testing.T) {
+
+	update := api.Update{InlineQuery: &api.InlineQuery{From: &api.User{ID: 1}}}
+	ctx := newCtx(nil, update, MakeEventFromUpdate(update), nil)
+
+	require.Nil(t, ctx.Chat)
+	require.Equal(t, chat.CSessionIDNil, ctx.SessionID)
+
+	_, err := ctx.Reply("text", nil)
+	require.Equal(t, ErrNoChat, err)
+
+	_, err = ctx.Edit("text", nil)
+	require.Equal(t, ErrNoMessage, err)
+
+	require.Equal(t, ErrNoCallbackQuery, ctx.AnswerCallback("", false))
+	require.Equal(t, ErrNoChat, ctx.SendChatAction(api.ChatTyping))
+}
@@ -6,11 +6,9 @@
 package tgbotapi
 
 import (
-	"reflect"
-	"unsafe"
-
 	"github.com/qioalice/devola/core/event"
 
+	"github.com/qioalice/devola-backend-telegram/api"
 	"github.com/qioalice/devola-backend-telegram/ikba"
 )
 
@@ -32,27 +30,67 @@ import (
 type Event struct {
 	event.Event
 
-	// TODO: Add arguments supporting (for example for commands)
+	// Parsed event's arguments.
+	// Not empty only if Type == CTypeCommand and command has arguments.
+	Args EventArgs `json:"-"`
+
+	// Message this event has been created from.
+	// Not nil only if event has been created from new or edited message
+	// or channel post (see MakeEventFromUpdate).
+	msg *api.Message `json:"-"`
 
 	// Encoded IKB action.
-	// It's a pointer to avoid reallocate memory for ikba.Encoded object
-	// and it is a pointer to the Data field with casted type.
+	// It's a decoded copy of the Data field, it doesn't refer to it.
 	//
-	// Not nil only if Type == CTypeInlineKeyboardButton.
+	// Not nil only if Type == CTypeInlineKeyboardButton
+	// and Data is a valid encoded IKB action.
 	ikbae *ikba.Encoded `json:"-"`
 }
 
 // MakeEvent creates a new Event object with passed event type and event data,
-// but also initializes IKB encoded action pointer if it is IKB event.
+// but also initializes IKB encoded action if it is IKB event.
+//
+// If it is IKB event but data is not a valid encoded IKB action,
+// IKB encoded action stays nil. Use MakeEventIKB to know why.
 func MakeEvent(typ event.Type, data event.Data) *Event {
 	var e Event
 	e.Type, e.Data = typ, data
 
-	// ikbae field will point to the Data field but with right type
 	if typ == CTypeInlineKeyboardButton {
-		dataHeader := (*reflect.StringHeader)(unsafe.Pointer(&e.Data))
-		e.ikbae = (*ikba.Encoded)(unsafe.Pointer(dataHeader.Data))
+		e.ikbae, _ = ikba.Decode(string(data))
 	}
 
 	return &e
 }
+
+// MakeEventIKB creates a new Event object with CTypeInlineKeyboardButton type
+// and callback data as event data, and decodes callback data to the
+// IKB encoded action.
+//
+// If callback data is not a valid encoded IKB action
+// (it's too short, too long or malformed), nil and error is returned.
+func MakeEventIKB(callbackData string) (*Event, error) {
+
+	ikbae, err := ikba.Decode(callbackData)
+	if err != nil {
+		return nil, err
+	}
+
+	var e Event
+	e.Type, e.Data = CTypeInlineKeyboardButton, event.Data(callbackData)
+	e.ikbae = ikbae
+
+	return &e, nil
+}
+
+// withMessage links message msg with event e and returns e.
+func (e *Event) withMessage(msg *api.Message) *Event {
+	e.msg = msg
+	return e
+}
+
+// Message returns the message event e has been created from.
+// Returns nil if event has not been created from message.
+func (e *Event) Message() *api.Message {
+	return e.msg
+}
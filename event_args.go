@@ -0,0 +1,180 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package tgbotapi
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// EventArgs represents parsed arguments of some event.
+//
+// At this moment only CTypeCommand events have arguments.
+// It's all text after command name, split to the positional tokens.
+// Tokens are separated by any amount of spaces, but the text
+// in double or single quotes is one token (quotes are removed).
+// Quote opens only at the start of token, thus apostrophes inside
+// words (don't, o'clock) are kept as is.
+// Inside quotes a backslash escapes the next character.
+//
+// Example:
+//
+// /find "red apple" 10 0.5 yes
+//
+// Tokens:
+//
+// 0: red apple, 1: 10, 2: 0.5, 3: yes.
+//
+// More info: Event, MakeEventArgs.
+type EventArgs struct {
+
+	// RAW arguments string, the arguments have been parsed from.
+	raw string
+
+	// Parsed positional tokens.
+	args []string
+}
+
+// MakeEventArgs parses raw arguments string and returns
+// an EventArgs object with parsed positional tokens.
+//
+// Unclosed quote is not an error: the rest of raw string is the last token.
+func MakeEventArgs(raw string) EventArgs {
+
+	var (
+		args    []string
+		token   strings.Builder
+		inToken = false
+		quote   rune
+		escaped = false
+	)
+
+	for _, r := range raw {
+		switch {
+
+		case escaped:
+			token.WriteRune(r)
+			escaped = false
+
+		case quote != 0 && r == '\\':
+			escaped = true
+
+		case quote != 0 && r == quote:
+			quote = 0
+
+		case quote != 0:
+			token.WriteRune(r)
+
+		case !inToken && (r == '"' || r == '\''):
+			quote, inToken = r, true
+
+		case unicode.IsSpace(r):
+			if inToken {
+				args = append(args, token.String())
+				token.Reset()
+				inToken = false
+			}
+
+		default:
+			token.WriteRune(r)
+			inToken = true
+		}
+	}
+
+	if inToken {
+		args = append(args, token.String())
+	}
+
+	return EventArgs{raw: raw, args: args}
+}
+
+// Raw returns the RAW arguments string, the arguments have been parsed from.
+func (a EventArgs) Raw() string {
+	return a.raw
+}
+
+// Len returns the number of parsed positional arguments.
+func (a EventArgs) Len() int {
+	return len(a.args)
+}
+
+// String returns idx positional argument as is.
+//
+// Returns it and true as success if it is, or zero value and false if error.
+func (a EventArgs) String(idx int) (v string, success bool) {
+
+	if idx < 0 || idx >= len(a.args) {
+		return "", false
+	}
+	return a.args[idx], true
+}
+
+// Int returns idx positional argument converted to int.
+//
+// Returns it and true as success if it is, or zero value and false if error
+// (argument is absent or it is not an integer).
+func (a EventArgs) Int(idx int) (v int, success bool) {
+
+	s, ok := a.String(idx)
+	if !ok {
+		return 0, false
+	}
+
+	vv, err := strconv.ParseInt(s, 10, 0)
+	if err != nil {
+		return 0, false
+	}
+	return int(vv), true
+}
+
+// Float returns idx positional argument converted to float64.
+// Both of dot and comma are allowed as a decimal separator.
+//
+// Returns it and true as success if it is, or zero value and false if error
+// (argument is absent or it is not a float).
+func (a EventArgs) Float(idx int) (v float64, success bool) {
+
+	s, ok := a.String(idx)
+	if !ok {
+		return 0, false
+	}
+
+	v, err := strconv.ParseFloat(strings.Replace(s, ",", ".", 1), 64)
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
+
+// Bool returns idx positional argument converted to bool.
+// Besides strconv.ParseBool's values, "yes", "no", "on", "off", "y", "n"
+// are allowed (case insensitive).
+//
+// Returns it and true as success if it is, or zero value and false if error
+// (argument is absent or it is not a bool).
+func (a EventArgs) Bool(idx int) (v bool, success bool) {
+
+	s, ok := a.String(idx)
+	if !ok {
+		return false, false
+	}
+
+	switch strings.ToLower(s) {
+
+	case "yes", "on", "y":
+		return true, true
+
+	case "no", "off", "n":
+		return false, true
+	}
+
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, false
+	}
+	return v, true
+}
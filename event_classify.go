@@ -0,0 +1,248 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package tgbotapi
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/qioalice/devola/core/event"
+
+	"github.com/qioalice/devola-backend-telegram/api"
+	"github.com/qioalice/devola-backend-telegram/ikba"
+)
+
+// MakeEventFromUpdate classifies passed Telegram Bot API update and creates
+// a new Event object with the right event type and event data.
+//
+// Each kind of update has its own event type (or a set of event types)
+// and its own convention of event data. Read the docs of event types
+// constants to know what event data is for each of them.
+//
+// If update can't be classified by any known event type,
+// an event with CTypeUnclassified type and empty data is returned.
+// Nil is never returned.
+func MakeEventFromUpdate(update api.Update) *Event {
+
+	switch {
+
+	case update.Message != nil:
+		return makeEventFromMessage(update.Message).withMessage(update.Message)
+
+	case update.EditedMessage != nil:
+		return MakeEvent(CTypeEditedMessage,
+			event.Data(messageText(update.EditedMessage))).
+			withMessage(update.EditedMessage)
+
+	case update.ChannelPost != nil:
+		return MakeEvent(CTypeChannelPost,
+			event.Data(messageText(update.ChannelPost))).
+			withMessage(update.ChannelPost)
+
+	case update.EditedChannelPost != nil:
+		return MakeEvent(CTypeEditedChannelPost,
+			event.Data(messageText(update.EditedChannelPost))).
+			withMessage(update.EditedChannelPost)
+
+	case update.CallbackQuery != nil && update.CallbackQuery.Data != "":
+		return makeEventFromCallbackData(update.CallbackQuery.Data)
+
+	case update.CallbackQuery != nil && update.CallbackQuery.GameShortName != "":
+		return MakeEvent(CTypeGameCallback,
+			event.Data(update.CallbackQuery.GameShortName))
+
+	case update.InlineQuery != nil:
+		return MakeEvent(CTypeInlineQuery,
+			event.Data(strings.TrimSpace(update.InlineQuery.Query)))
+
+	case update.ChosenInlineResult != nil:
+		return MakeEvent(CTypeChosenInlineResult,
+			event.Data(update.ChosenInlineResult.ResultID))
+
+	case update.ShippingQuery != nil:
+		return MakeEvent(CTypeShippingQuery,
+			event.Data(update.ShippingQuery.InvoicePayload))
+
+	case update.PreCheckoutQuery != nil:
+		return MakeEvent(CTypePreCheckoutQuery,
+			event.Data(update.PreCheckoutQuery.InvoicePayload))
+
+	default:
+		return MakeEvent(CTypeUnclassified, "")
+	}
+}
+
+// makeEventFromCallbackData creates a new Event object with
+// CTypeInlineKeyboardButton type if callbackData is a valid encoded IKB action,
+// with CTypeExpiredInlineKeyboardButton type if it's a valid, but expired
+// encoded IKB action, or with CTypeInvalidInlineKeyboardButton type otherwise.
+func makeEventFromCallbackData(callbackData string) *Event {
+
+	switch e, err := MakeEventIKB(callbackData); err {
+
+	case nil:
+		return e
+
+	case ikba.ErrActionExpired, ikba.ErrExpired:
+		return MakeEvent(CTypeExpiredInlineKeyboardButton, event.Data(callbackData))
+
+	default:
+		return MakeEvent(CTypeInvalidInlineKeyboardButton, event.Data(callbackData))
+	}
+}
+
+// makeEventFromMessage classifies passed new (not edited) message
+// and creates a new Event object.
+//
+// Service messages are checked first, then media messages,
+// then commands, then text.
+func makeEventFromMessage(msg *api.Message) *Event {
+
+	if e := makeEventFromServiceMessage(msg); e != nil {
+		return e
+	}
+
+	if e := makeEventFromMediaMessage(msg); e != nil {
+		return e
+	}
+
+	switch {
+
+	case msg.IsCommand():
+		e := MakeEvent(CTypeCommand,
+			event.Data(strings.ToLower(msg.Command())))
+		e.Args = MakeEventArgs(msg.CommandArguments())
+		return e
+
+	case msg.Text != "":
+		return MakeEvent(CTypeText,
+			event.Data(strings.TrimSpace(msg.Text)))
+
+	default:
+		return MakeEvent(CTypeUnclassified, "")
+	}
+}
+
+// makeEventFromServiceMessage classifies passed message as service message
+// and creates a new Event object.
+//
+// If msg is not a service message, nil is returned.
+func makeEventFromServiceMessage(msg *api.Message) *Event {
+
+	switch {
+
+	case len(msg.NewChatMembers) != 0:
+		ids := make([]string, len(msg.NewChatMembers))
+		for i := range msg.NewChatMembers {
+			ids[i] = strconv.Itoa(msg.NewChatMembers[i].ID)
+		}
+		return MakeEvent(CTypeNewChatMembers,
+			event.Data(strings.Join(ids, " ")))
+
+	case msg.LeftChatMember != nil:
+		return MakeEvent(CTypeLeftChatMember,
+			event.Data(strconv.Itoa(msg.LeftChatMember.ID)))
+
+	case msg.NewChatTitle != "":
+		return MakeEvent(CTypeNewChatTitle,
+			event.Data(msg.NewChatTitle))
+
+	case len(msg.NewChatPhoto) != 0:
+		return MakeEvent(CTypeNewChatPhoto, "")
+
+	case msg.DeleteChatPhoto:
+		return MakeEvent(CTypeDeleteChatPhoto, "")
+
+	case msg.GroupChatCreated:
+		return MakeEvent(CTypeChatCreated, "group")
+
+	case msg.SuperGroupChatCreated:
+		return MakeEvent(CTypeChatCreated, "supergroup")
+
+	case msg.ChannelChatCreated:
+		return MakeEvent(CTypeChatCreated, "channel")
+
+	case msg.MigrateToChatID != 0:
+		return MakeEvent(CTypeMigrateToChat,
+			event.Data(strconv.FormatInt(msg.MigrateToChatID, 10)))
+
+	case msg.MigrateFromChatID != 0:
+		return MakeEvent(CTypeMigrateFromChat,
+			event.Data(strconv.FormatInt(msg.MigrateFromChatID, 10)))
+
+	case msg.PinnedMessage != nil:
+		return MakeEvent(CTypePinnedMessage,
+			event.Data(messageText(msg.PinnedMessage)))
+
+	case msg.SuccessfulPayment != nil:
+		return MakeEvent(CTypeSuccessfulPayment,
+			event.Data(msg.SuccessfulPayment.InvoicePayload))
+
+	default:
+		return nil
+	}
+}
+
+// makeEventFromMediaMessage classifies passed message as media message
+// and creates a new Event object.
+//
+// Venue is checked before location and animation is checked before document,
+// because Telegram fills both of fields for these messages.
+//
+// If msg is not a media message, nil is returned.
+func makeEventFromMediaMessage(msg *api.Message) *Event {
+
+	caption := event.Data(strings.TrimSpace(msg.Caption))
+
+	switch {
+
+	case len(msg.Photo) != 0:
+		return MakeEvent(CTypePhoto, caption)
+
+	case msg.Voice != nil:
+		return MakeEvent(CTypeVoice, caption)
+
+	case msg.Video != nil:
+		return MakeEvent(CTypeVideo, caption)
+
+	case msg.VideoNote != nil:
+		return MakeEvent(CTypeVideoNote, "")
+
+	case msg.Audio != nil:
+		return MakeEvent(CTypeAudio, caption)
+
+	case msg.Animation != nil:
+		return MakeEvent(CTypeAnimation, caption)
+
+	case msg.Document != nil:
+		return MakeEvent(CTypeDocument, caption)
+
+	case msg.Sticker != nil:
+		return MakeEvent(CTypeSticker, event.Data(msg.Sticker.Emoji))
+
+	case msg.Venue != nil:
+		return MakeEvent(CTypeVenue, event.Data(msg.Venue.Title))
+
+	case msg.Location != nil:
+		return MakeEvent(CTypeLocation, "")
+
+	case msg.Contact != nil:
+		return MakeEvent(CTypeContact, event.Data(msg.Contact.PhoneNumber))
+
+	default:
+		return nil
+	}
+}
+
+// messageText returns the text of message msg, or its caption if message
+// has no text, with trimmed leading and trailing spaces.
+func messageText(msg *api.Message) string {
+
+	if msg.Text != "" {
+		return strings.TrimSpace(msg.Text)
+	}
+	return strings.TrimSpace(msg.Caption)
+}
@@ -0,0 +1,117 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package tgbotapi
+
+import (
+	"github.com/qioalice/devola-backend-telegram/api"
+)
+
+// Photo returns all available sizes of photo from the message
+// event e has been created from.
+// Returns nil if it's not a photo message.
+func (e *Event) Photo() []api.PhotoSize {
+	if e.msg == nil {
+		return nil
+	}
+	return e.msg.Photo
+}
+
+// Voice returns the voice from the message event e has been created from.
+// Returns nil if it's not a voice message.
+func (e *Event) Voice() *api.Voice {
+	if e.msg == nil {
+		return nil
+	}
+	return e.msg.Voice
+}
+
+// Video returns the video from the message event e has been created from.
+// Returns nil if it's not a video message.
+func (e *Event) Video() *api.Video {
+	if e.msg == nil {
+		return nil
+	}
+	return e.msg.Video
+}
+
+// VideoNote returns the video note from the message event e has been
+// created from.
+// Returns nil if it's not a video note message.
+func (e *Event) VideoNote() *api.VideoNote {
+	if e.msg == nil {
+		return nil
+	}
+	return e.msg.VideoNote
+}
+
+// Audio returns the audio from the message event e has been created from.
+// Returns nil if it's not an audio message.
+func (e *Event) Audio() *api.Audio {
+	if e.msg == nil {
+		return nil
+	}
+	return e.msg.Audio
+}
+
+// Animation returns the animation from the message event e has been
+// created from.
+// Returns nil if it's not an animation message.
+func (e *Event) Animation() *api.ChatAnimation {
+	if e.msg == nil {
+		return nil
+	}
+	return e.msg.Animation
+}
+
+// Document returns the document from the message event e has been
+// created from.
+// Returns nil if it's not a document message.
+func (e *Event) Document() *api.Document {
+	if e.msg == nil {
+		return nil
+	}
+	return e.msg.Document
+}
+
+// Sticker returns the sticker from the message event e has been created from.
+// Returns nil if it's not a sticker message.
+func (e *Event) Sticker() *api.Sticker {
+	if e.msg == nil {
+		return nil
+	}
+	return e.msg.Sticker
+}
+
+// Location returns the location from the message event e has been
+// created from.
+// Returns nil if it's not a location or venue message.
+func (e *Event) Location() *api.Location {
+	if e.msg == nil {
+		return nil
+	}
+	if e.msg.Location == nil && e.msg.Venue != nil {
+		return &e.msg.Venue.Location
+	}
+	return e.msg.Location
+}
+
+// Venue returns the venue from the message event e has been created from.
+// Returns nil if it's not a venue message.
+func (e *Event) Venue() *api.Venue {
+	if e.msg == nil {
+		return nil
+	}
+	return e.msg.Venue
+}
+
+// Contact returns the contact from the message event e has been created from.
+// Returns nil if it's not a contact message.
+func (e *Event) Contact() *api.Contact {
+	if e.msg == nil {
+		return nil
+	}
+	return e.msg.Contact
+}
@@ -0,0 +1,211 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package tgbotapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/qioalice/devola-backend-telegram/api"
+	"github.com/qioalice/devola-backend-telegram/ikba"
+)
+
+// newCommandUpdate returns an update with message with text that starts
+// with a command which length is cmdLen.
+func newCommandUpdate(text string, cmdLen int) api.Update {
+	msg := &api.Message{Text: text}
+	msg.Entities = []api.MessageEntity{{Type: "bot_command", Offset: 0, Length: cmdLen}}
+	return api.Update{Message: msg}
+}
+
+func TestMakeEventFromUpdateCommand(t *testing.T) {
+
+	e := MakeEventFromUpdate(newCommandUpdate("/StArT@MyBot arg", 12))
+	require.Equal(t, CTypeCommand, e.Type)
+	require.EqualValues(t, "start", e.Data)
+}
+
+func TestMakeEventFromUpdateText(t *testing.T) {
+
+	e := MakeEventFromUpdate(api.Update{Message: &api.Message{Text: "  hello  "}})
+	require.Equal(t, CTypeText, e.Type)
+	require.EqualValues(t, "hello", e.Data)
+}
+
+func TestMakeEventFromUpdateUnclassified(t *testing.T) {
+
+	e := MakeEventFromUpdate(api.Update{})
+	require.Equal(t, CTypeUnclassified, e.Type)
+	require.EqualValues(t, "", e.Data)
+
+	e = MakeEventFromUpdate(api.Update{CallbackQuery: &api.CallbackQuery{}})
+	require.Equal(t, CTypeUnclassified, e.Type)
+}
+
+func TestMakeEventFromUpdateCommandArgs(t *testing.T) {
+
+	e := MakeEventFromUpdate(newCommandUpdate(`/find "red apple" 10 0,5 yes`, 5))
+	require.Equal(t, 4, e.Args.Len())
+
+	s, ok := e.Args.String(0)
+	require.True(t, ok)
+	require.Equal(t, "red apple", s)
+
+	i, ok := e.Args.Int(1)
+	require.True(t, ok)
+	require.Equal(t, 10, i)
+
+	f, ok := e.Args.Float(2)
+	require.True(t, ok)
+	require.Equal(t, 0.5, f)
+
+	b, ok := e.Args.Bool(3)
+	require.True(t, ok)
+	require.True(t, b)
+
+	_, ok = e.Args.Int(0)
+	require.False(t, ok)
+
+	_, ok = e.Args.String(4)
+	require.False(t, ok)
+}
+
+func TestMakeEventArgsQuotes(t *testing.T) {
+
+	a := MakeEventArgs(`  'it\'s'  "" "a \"b\" c"   d  `)
+	require.Equal(t, []string{"it's", "", `a "b" c`, "d"}, a.args)
+
+	a = MakeEventArgs(`one "two three`)
+	require.Equal(t, []string{"one", "two three"}, a.args)
+
+	a = MakeEventArgs(`don't stop me`)
+	require.Equal(t, []string{"don't", "stop", "me"}, a.args)
+
+	a = MakeEventArgs(`5 o'clock "x y"`)
+	require.Equal(t, []string{"5", "o'clock", "x y"}, a.args)
+
+	a = MakeEventArgs("")
+	require.Equal(t, 0, a.Len())
+}
+
+func TestMakeEventFromUpdateKinds(t *testing.T) {
+
+	e := MakeEventFromUpdate(api.Update{EditedMessage: &api.Message{Caption: " new "}})
+	require.Equal(t, CTypeEditedMessage, e.Type)
+	require.EqualValues(t, "new", e.Data)
+
+	e = MakeEventFromUpdate(api.Update{InlineQuery: &api.InlineQuery{Query: "cats"}})
+	require.Equal(t, CTypeInlineQuery, e.Type)
+	require.EqualValues(t, "cats", e.Data)
+
+	e = MakeEventFromUpdate(api.Update{PreCheckoutQuery: &api.PreCheckoutQuery{InvoicePayload: "order"}})
+	require.Equal(t, CTypePreCheckoutQuery, e.Type)
+	require.EqualValues(t, "order", e.Data)
+
+	e = MakeEventFromUpdate(api.Update{Message: &api.Message{
+		NewChatMembers: []api.User{{ID: 1}, {ID: 2}},
+	}})
+	require.Equal(t, CTypeNewChatMembers, e.Type)
+	require.EqualValues(t, "1 2", e.Data)
+
+	e = MakeEventFromUpdate(api.Update{Message: &api.Message{MigrateToChatID: -100}})
+	require.Equal(t, CTypeMigrateToChat, e.Type)
+	require.EqualValues(t, "-100", e.Data)
+}
+
+func TestMakeEventFromUpdateMedia(t *testing.T) {
+
+	voice := &api.Voice{FileID: "voice"}
+	e := MakeEventFromUpdate(api.Update{Message: &api.Message{Voice: voice, Caption: " hi "}})
+	require.Equal(t, CTypeVoice, e.Type)
+	require.EqualValues(t, "hi", e.Data)
+	require.True(t, voice == e.Voice())
+	require.Nil(t, e.Photo())
+
+	venue := &api.Venue{Title: "Cafe", Location: api.Location{Latitude: 1}}
+	e = MakeEventFromUpdate(api.Update{Message: &api.Message{Venue: venue}})
+	require.Equal(t, CTypeVenue, e.Type)
+	require.EqualValues(t, "Cafe", e.Data)
+	require.Equal(t, float64(1), e.Location().Latitude)
+
+	e = MakeEventFromUpdate(api.Update{})
+	require.Nil(t, e.Message())
+	require.Nil(t, e.Voice())
+}
+
+func TestMakeEventFromUpdateIKB(t *testing.T) {
+
+	ikbae := ikba.New()
+	ikbae.PutArgInt32(10)
+
+	e := MakeEventFromUpdate(api.Update{CallbackQuery: &api.CallbackQuery{
+		Data: ikbae.CallbackData(),
+	}})
+	require.Equal(t, CTypeInlineKeyboardButton, e.Type)
+	require.Equal(t, ikbae, e.ikbae)
+
+	e = MakeEventFromUpdate(api.Update{CallbackQuery: &api.CallbackQuery{
+		Data: "short",
+	}})
+	require.Equal(t, CTypeInvalidInlineKeyboardButton, e.Type)
+	require.EqualValues(t, "short", e.Data)
+	require.Nil(t, e.ikbae)
+
+	_, err := MakeEventIKB("short")
+	require.Equal(t, ikba.ErrBadLength, err)
+}
+
+func TestMakeEventFromUpdateSignedIKB(t *testing.T) {
+
+	ikba.SetSecret([]byte("secret"))
+	defer ikba.SetSecret(nil)
+
+	ikbae := ikba.New()
+	ikbae.PutArgInt32(10)
+
+	e := MakeEventFromUpdate(api.Update{CallbackQuery: &api.CallbackQuery{
+		Data: ikbae.CallbackData(),
+	}})
+	require.Equal(t, CTypeInlineKeyboardButton, e.Type)
+	require.Equal(t, ikbae, e.ikbae)
+
+	ikba.SetSecret(nil)
+	forged := ikbae.CallbackData()
+	ikba.SetSecret([]byte("secret"))
+
+	e = MakeEventFromUpdate(api.Update{CallbackQuery: &api.CallbackQuery{
+		Data: forged,
+	}})
+	require.Equal(t, CTypeInvalidInlineKeyboardButton, e.Type)
+	require.Nil(t, e.ikbae)
+
+	_, err := MakeEventIKB(forged)
+	require.Equal(t, ikba.ErrBadSignature, err)
+}
+
+func TestMakeEventFromUpdateExpiredIKB(t *testing.T) {
+
+	ikbae := ikba.New()
+	ikbae.PutArgInt32(10)
+	require.True(t, ikbae.SetExpiry(time.Now().Add(-time.Hour)))
+
+	e := MakeEventFromUpdate(api.Update{CallbackQuery: &api.CallbackQuery{
+		Data: ikbae.CallbackData(),
+	}})
+	require.Equal(t, CTypeExpiredInlineKeyboardButton, e.Type)
+	require.EqualValues(t, ikbae.CallbackData(), e.Data)
+	require.Nil(t, e.ikbae)
+
+	require.True(t, ikbae.SetExpiry(time.Now().Add(time.Hour)))
+
+	e = MakeEventFromUpdate(api.Update{CallbackQuery: &api.CallbackQuery{
+		Data: ikbae.CallbackData(),
+	}})
+	require.Equal(t, CTypeInlineKeyboardButton, e.Type)
+	require.Equal(t, ikbae, e.ikbae)
+}
@@ -12,16 +12,33 @@ import (
 // Constants of Type.
 // Use these constants to figure out what kind of event is occurred
 // (by comparing Type).
+//
+// Event types are grouped by hundreds:
+// 1xx - new and edited messages in chats and channels,
+// 2xx - callback queries (pressed inline keyboard buttons),
+// 3xx - inline mode,
+// 4xx - payments,
+// 5xx - service messages.
+//
+// More info: MakeEventFromUpdate.
 const (
 
+	// Unclassified event.
+	// An update has been received but it can't be classified by any
+	// known event type (see MakeEventFromUpdate).
+	// tEvent's Data field is empty.
+	CTypeUnclassified event.Type = 0
+
 	// Chat text command.
 	// tEvent's Data field represents a lowercase command without arguments.
+	// tEvent's Args field represents parsed command arguments.
 	CTypeCommand event.Type = 100
 
 	// Pressed keyboard button.
 	// Technically this is a text (in a chat), but a text sent by
 	// pressing to the keyboard button.
 	// tEvent's Data field represents this keyboard button data.
+	// Can be detected only by KeyboardRegistry.MakeEventFromUpdate.
 	CTypeKeyboardButton event.Type = 101
 
 	// Typed text.
@@ -29,8 +46,162 @@ const (
 	// leading and trailing spaces.
 	CTypeText event.Type = 102
 
+	// Edited message.
+	// tEvent's Data field represents the new text (or caption) of message
+	// but with trimmed leading and trailing spaces.
+	CTypeEditedMessage event.Type = 103
+
+	// Photo message.
+	// tEvent's Data field represents the photo's caption
+	// but with trimmed leading and trailing spaces.
+	// Use Event.Photo to get photo's sizes.
+	CTypePhoto event.Type = 120
+
+	// Voice message.
+	// tEvent's Data field represents the voice's caption
+	// but with trimmed leading and trailing spaces.
+	// Use Event.Voice to get voice.
+	CTypeVoice event.Type = 121
+
+	// Video message.
+	// tEvent's Data field represents the video's caption
+	// but with trimmed leading and trailing spaces.
+	// Use Event.Video to get video.
+	CTypeVideo event.Type = 122
+
+	// Video note message (round video).
+	// tEvent's Data field is empty.
+	// Use Event.VideoNote to get video note.
+	CTypeVideoNote event.Type = 123
+
+	// Audio message.
+	// tEvent's Data field represents the audio's caption
+	// but with trimmed leading and trailing spaces.
+	// Use Event.Audio to get audio.
+	CTypeAudio event.Type = 124
+
+	// Animation (GIF or H.264/MPEG-4 AVC video without sound) message.
+	// tEvent's Data field represents the animation's caption
+	// but with trimmed leading and trailing spaces.
+	// Use Event.Animation to get animation.
+	CTypeAnimation event.Type = 125
+
+	// Document (general file) message.
+	// tEvent's Data field represents the document's caption
+	// but with trimmed leading and trailing spaces.
+	// Use Event.Document to get document.
+	CTypeDocument event.Type = 126
+
+	// Sticker message.
+	// tEvent's Data field represents the sticker's emoji (may be empty).
+	// Use Event.Sticker to get sticker.
+	CTypeSticker event.Type = 127
+
+	// Location message.
+	// tEvent's Data field is empty.
+	// Use Event.Location to get location.
+	CTypeLocation event.Type = 130
+
+	// Venue message.
+	// tEvent's Data field represents the venue's title.
+	// Use Event.Venue to get venue (and Event.Location to get its location).
+	CTypeVenue event.Type = 131
+
+	// Contact message.
+	// tEvent's Data field represents the contact's phone number.
+	// Use Event.Contact to get contact.
+	CTypeContact event.Type = 132
+
+	// New channel post.
+	// tEvent's Data field represents the text (or caption) of post
+	// but with trimmed leading and trailing spaces.
+	CTypeChannelPost event.Type = 110
+
+	// Edited channel post.
+	// tEvent's Data field represents the new text (or caption) of post
+	// but with trimmed leading and trailing spaces.
+	CTypeEditedChannelPost event.Type = 111
+
 	// Pressed inline keyboard button.
 	// tEvent's Data field stored TAction value, representing the your
 	// action causes occurred event.
 	CTypeInlineKeyboardButton event.Type = 200
+
+	// Pressed inline keyboard button that launches a game.
+	// tEvent's Data field represents the game's short name.
+	CTypeGameCallback event.Type = 201
+
+	// Pressed inline keyboard button with malformed, foreign or forged
+	// callback data (it's not a valid encoded IKB action, its signature
+	// doesn't match, see ikba.SetSecret, or its encrypted arguments have been
+	// tampered, see ikba.SetKey).
+	// tEvent's Data field represents callback data as is.
+	CTypeInvalidInlineKeyboardButton event.Type = 202
+
+	// Pressed inline keyboard button which encoded IKB action has expired
+	// (its deadline has passed, see ikba.Encoded.SetExpiry, or its stored
+	// argument has expired, see ikba.SetStore).
+	// tEvent's Data field represents callback data as is.
+	CTypeExpiredInlineKeyboardButton event.Type = 203
+
+	// Inline query.
+	// tEvent's Data field represents the query text but with trimmed
+	// leading and trailing spaces.
+	CTypeInlineQuery event.Type = 300
+
+	// Chosen inline query result.
+	// tEvent's Data field represents the ID of chosen result.
+	CTypeChosenInlineResult event.Type = 301
+
+	// Shipping query (for invoices with flexible price).
+	// tEvent's Data field represents the invoice payload.
+	CTypeShippingQuery event.Type = 400
+
+	// Pre-checkout query.
+	// tEvent's Data field represents the invoice payload.
+	CTypePreCheckoutQuery event.Type = 401
+
+	// Successful payment service message.
+	// tEvent's Data field represents the invoice payload.
+	CTypeSuccessfulPayment event.Type = 402
+
+	// New chat members service message.
+	// tEvent's Data field represents the space separated IDs of new members.
+	CTypeNewChatMembers event.Type = 500
+
+	// Left chat member service message.
+	// tEvent's Data field represents the ID of left member.
+	CTypeLeftChatMember event.Type = 501
+
+	// Changed chat title service message.
+	// tEvent's Data field represents the new chat title.
+	CTypeNewChatTitle event.Type = 502
+
+	// Changed chat photo service message.
+	// tEvent's Data field is empty.
+	CTypeNewChatPhoto event.Type = 503
+
+	// Deleted chat photo service message.
+	// tEvent's Data field is empty.
+	CTypeDeleteChatPhoto event.Type = 504
+
+	// Created chat service message.
+	// tEvent's Data field represents the type of created chat:
+	// "group", "supergroup" or "channel".
+	CTypeChatCreated event.Type = 505
+
+	// Chat has been migrated to the supergroup service message
+	// (sent to the old chat).
+	// tEvent's Data field represents the ID of new supergroup.
+	CTypeMigrateToChat event.Type = 506
+
+	// Chat has been migrated from the group service message
+	// (sent to the new supergroup).
+	// tEvent's Data field represents the ID of old group.
+	CTypeMigrateFromChat event.Type = 507
+
+	// Pinned message service message.
+	// tEvent's Data field represents the text (or caption) of pinned message
+	// but with trimmed leading and trailing spaces.
+	CTypePinnedMessage event.Type = 508
 )
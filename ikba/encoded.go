@@ -6,15 +6,13 @@
 package ikba
 
 import (
+	"encoding/binary"
 	"unsafe"
 
 	"github.com/qioalice/devola/core/chat"
 	"github.com/qioalice/devola/core/view"
 )
 
-// TODO: Add negative index GetArg support.
-// TODO: Add named arguments.
-
 // Encoded is the internal type that represents encoded
 // a Telegram Inline Keyboard Button (IKB) action.
 //
@@ -25,14 +23,28 @@ import (
 // action of Inline Keyboard Button as some string that must have length
 // not more than 64 byte.
 //
+// Moreover this string must be a valid UTF-8 string, so binary data
+// can't be used as is. Thus the used bytes of Encoded are encoded
+// by Ascii85 (4 bytes to 5 printable ASCII chars) to the callback data
+// (see CallbackData, Decode) after the format version marker (1 char,
+// see Version), and only 50 bytes of Encoded can be used
+// (51 bytes for legacy callback data without version marker).
+//
 // The encode/decode algorithm described below.
 //
+// Callback data view:
+//
+// < Version marker : 1 char >
+// < Ascii85 of used bytes of Encoded (and nonce, signature) : N chars >
+//
 // Encoded view of Encoded:
 //
 // < Action ID : sizeof(Encoded) (now 4 byte) >
 // < Session ID : sizeof(tSessionID) (now 4 byte) >
 // < Args count : 1 byte >
 // < Index over last encoded argument : 1 byte >
+// < Expiry : 2-11 bytes (only if it's set, see SetExpiry) >
+// < Arg 1 Key : 2 bytes (only for named arguments) >
 // < Arg 1 Type : 1 byte >
 // < Arg 1 Value : N bytes (depends by Arg 1 Type) > ...
 //
@@ -65,11 +77,23 @@ const (
 	// Max allowable position in Encoded.
 	cPosMax byte = 63
 
+	// Max allowable next free position in Encoded (the number of used bytes)
+	// that still can be encoded to the Telegram callback data
+	// with format version marker (see CallbackData, cCallbackDataMax, Version).
+	cPosFreeMax byte = 50
+
 	// Error position value.
 	// Returned from some methods.
 	cPosErr byte = ^byte(0)
 )
 
+// Predefined constants of Telegram callback data.
+const (
+
+	// Max length of Telegram inline keyboard button's callback data (in bytes).
+	cCallbackDataMax = 64
+)
+
 // Predefined index constants that means a special cases of encode/decode operations.
 const (
 
@@ -85,7 +109,9 @@ const (
 // ATTENTION!
 // DO NOT FORGET ADD ALL NEW CONSTANTS TO THE argNextFromPos METHOD'S SWITCH!
 // DO NOT FORGET ADD ALL NEW CONSTANTS TO THE argType2S METHOD'S SWITCH!
-// DO NOT FORGET ADD BEHAVIOUR FOR NEW TYPES TO THE dump METHOD!
+// DO NOT FORGET ADD BEHAVIOUR FOR NEW TYPES TO THE argEnd METHOD (Dump, validate)!
+// DO NOT FORGET ADD READING OF NEW TYPES TO THE argValue METHOD!
+// DO NOT FORGET ADD NEW TYPES TO THE ArgType CONSTANTS!
 //
 // ATTENTION!
 // DO NOT OVERFLOW INT8 (1<<7) -1 (127). BECAUSE!
@@ -123,6 +149,63 @@ const (
 
 	// Header of string argument
 	cArgTypeString byte = 20
+
+	// Header of argument's key (named argument).
+	// It's not an argument itself, it's a prefix of argument:
+	// < Key header : 1 byte > < Key ID : 1 byte > < Argument ... >
+	// Thus named argument is a positional argument too.
+	cArgTypeKey byte = 21
+
+	// Header of signed varint argument (zigzag encoded, 1-10 bytes,
+	// see encoding/binary.PutVarint)
+	cArgTypeVarint byte = 22
+
+	// Header of unsigned varint argument (1-10 bytes,
+	// see encoding/binary.PutUvarint)
+	cArgTypeUvarint byte = 23
+
+	// Header of bool argument with false value.
+	// Bool argument has no content, the value is the header itself.
+	cArgTypeFalse byte = 24
+
+	// Header of bool argument with true value (see cArgTypeFalse).
+	cArgTypeTrue byte = 25
+
+	// Header of bit-packed bools argument:
+	// < Header : 1 byte > < Number of bools : 1 byte > < Bits : N bytes >
+	// i-th bool is (i%8)-th bit of (i/8)-th byte.
+	cArgTypeBools byte = 26
+
+	// Header of bytes argument:
+	// < Header : 1 byte > < Length : 1 byte > < Content : N bytes >
+	cArgTypeBytes byte = 27
+
+	// Header of stored string argument (string is kept in the store):
+	// < Header : 1 byte > < Key : 8 bytes >
+	cArgTypeStoredString byte = 28
+
+	// Header of stored bytes argument (see cArgTypeStoredString).
+	cArgTypeStoredBytes byte = 29
+
+	// Header of expiry (see SetExpiry). It's not an argument:
+	// it's placed only right before the first argument and
+	// it's not counted by arguments' counter:
+	// < Header : 1 byte > < Minutes since Epoch : uvarint >
+	cArgTypeExpiry byte = 30
+
+	// Header of packed string argument of decimal digits (4 bits per char):
+	// < Header : 1 byte > < Number of chars : 1 byte > < Bits : N bytes >
+	// i-th char is the index of char in the alphabet (see packedAlphabet)
+	// that takes bits from (i*width)-th bit (the same bit order as bools).
+	cArgTypeDigits byte = 31
+
+	// Header of packed string argument of lowercase hex digits (4 bits
+	// per char, see cArgTypeDigits).
+	cArgTypeHex byte = 32
+
+	// Header of packed string argument of lowercase alphanumerics (6 bits
+	// per char, see cArgTypeDigits).
+	cArgTypeAlnum byte = 33
 )
 
 // ext1byte extracts 1 byte from encoded IKB action d starts from startPos
@@ -235,15 +318,19 @@ func (d *Encoded) GetSessionID() chat.SessionID {
 }
 
 // needForType returns the number of bytes that required to store
-// an argument's value with type argType.
+// an argument's value with type argType (including type header).
 //
 // WARNING!
-// Only for any integer or float type.
+// Only for fixed width types (fixed width integers, floats, bools).
 // Calls with other type's constant will return a very big value.
 func (*Encoded) argNeedForType(argType byte) (numBytes byte) {
 
 	switch argType {
 
+	case cArgTypeFalse,
+		cArgTypeTrue:
+		return 1
+
 	case cArgTypeInt8,
 		cArgTypeUint8:
 		return 2
@@ -259,7 +346,9 @@ func (*Encoded) argNeedForType(argType byte) (numBytes byte) {
 
 	case cArgTypeInt64,
 		cArgTypeUint64,
-		cArgTypeFloat64:
+		cArgTypeFloat64,
+		cArgTypeStoredString,
+		cArgTypeStoredBytes:
 		return 9
 
 	default:
@@ -267,11 +356,18 @@ func (*Encoded) argNeedForType(argType byte) (numBytes byte) {
 	}
 }
 
+// posFreeMax returns the max allowable next free position in Encoded
+// taking into account the signature (see SetSecret) and nonce (see SetKey).
+func posFreeMax() byte {
+	return cPosFreeMax - signatureLen() - nonceLen()
+}
+
 // argHaveFreeBytes returns true only if numBytes bytes of some argument
-// can be saved into current encoded action. Otherwise false is returned.
+// can be saved into current encoded action and encoded action still can be
+// encoded to the Telegram callback data. Otherwise false is returned.
 func (d *Encoded) argHaveFreeBytes(numBytes byte) bool {
-	return d[cPosArgsFree]+numBytes <=
-		cPosMax
+	return int(d[cPosArgsFree])+int(numBytes) <=
+		int(posFreeMax())
 }
 
 // argReserveForType reserves the number of bytes for argument with type argType
@@ -297,8 +393,8 @@ func (d *Encoded) argReserveForType(argType byte) (startPos byte) {
 	startPos = d[cPosArgsFree]
 	nextStartPos := startPos + requiredBytes
 
-	// Check whether nextStartPos <= max allowable position
-	if nextStartPos > cPosMax {
+	// Check whether nextStartPos <= max allowable next free position
+	if nextStartPos > posFreeMax() {
 		return cPosErr
 	}
 
@@ -308,10 +404,51 @@ func (d *Encoded) argReserveForType(argType byte) (startPos byte) {
 	return startPos + 1
 }
 
+// argIdxResolve returns the non-negative index of argument with index argIdx.
+// Negative index counts from the end: -1 is the last argument,
+// -2 is the one before it, etc.
+//
+// If index is out of range, cBadIndex is returned.
+func (d *Encoded) argIdxResolve(argIdx int) (idx int) {
+
+	argCount := d.ArgCount()
+	if argIdx < 0 {
+		argIdx += argCount
+	}
+
+	if argIdx < 0 || argIdx >= argCount {
+		return cBadIndex
+	}
+	return argIdx
+}
+
+// argPos returns a position where argument with index argIdx
+// (its type header) starts from.
+//
+// If index is out of range (negative index is allowed, see argIdxResolve)
+// or d is malformed, cPosErr is returned.
+func (d *Encoded) argPos(argIdx int) (pos byte) {
+
+	if argIdx = d.argIdxResolve(argIdx); argIdx == cBadIndex {
+		return cPosErr
+	}
+
+	// Skip unnecessary arguments
+	pos = d.argsStart()
+	for ; argIdx > 0 && pos != cPosErr; argIdx-- {
+		pos = d.argNextFromPos(pos)
+	}
+	return pos
+}
+
 // argGet returns a position where argument's content with type argType
-// starts from. The search begins from idx argument index.
+// (or any of argTypes) starts from. The search begins from idx argument index.
+// Negative index counts from the end (see argIdxResolve).
+//
+// Thus the found argument may have index greater than idx.
+// Use argGetAt to get argument with exactly idx index.
 //
-// If index is too long, argument not exists or something wrong else,
+// If index is out of range, argument not exists or something wrong else,
 // cPosErr is returned.
 //
 // Example:
@@ -324,27 +461,20 @@ func (d *Encoded) argReserveForType(argType byte) (startPos byte) {
 // argGet(0, int32) == pos of content of 0 arg.
 // argGet(0, string) == pos of content of 2 arg.
 // argGet(4, int8) == pos of content of 4 arg.
+// argGet(-1, int8) == pos of content of 4 arg.
+// argGet(-3, int8) == pos of content of 3 arg.
 //
 // All types presented above are constants, of course.
-func (d *Encoded) argGet(argIdx int, argType byte) (startPos byte) {
-
-	// Check index is valid
-	if d.ArgCount() <= argIdx {
-		return cPosErr
-	}
+func (d *Encoded) argGet(argIdx int, argTypes ...byte) (startPos byte) {
 
-	// Skip unnecessary arguments (argIdx -1)
-	startPos = cPosArgsContent
-	for argIdx--; argIdx > 0; argIdx-- {
-		startPos = d.argNextFromPos(startPos)
-	}
+	startPos = d.argPos(argIdx)
 
 	// Try to find required argument
 	nextFreeIndex := d[cPosArgsFree]
 	for startPos != cPosErr && startPos < nextFreeIndex {
-		if d[startPos] == argType {
+		if headerPos := d.argSkipKey(startPos); argTypeIs(d[headerPos], argTypes...) {
 			// Found, return argument's content position
-			return startPos + 1
+			return headerPos + 1
 		}
 		// Go to next arg
 		startPos = d.argNextFromPos(startPos)
@@ -354,6 +484,109 @@ func (d *Encoded) argGet(argIdx int, argType byte) (startPos byte) {
 	return cPosErr
 }
 
+// argSkipKey returns the position of argument's type header if pos is
+// position of some argument. It's pos itself for positional arguments
+// and the position after the key for named arguments.
+func (d *Encoded) argSkipKey(pos byte) (headerPos byte) {
+
+	if d[pos] == cArgTypeKey {
+		return pos + 2
+	}
+	return pos
+}
+
+// argValue returns the typed value of argument which type header
+// is at headerPos (not a key, use argSkipKey).
+//
+// If type header is unknown, nil and false is returned.
+func (d *Encoded) argValue(headerPos byte) (v interface{}, success bool) {
+
+	pos := headerPos + 1
+
+	switch d[headerPos] {
+
+	case cArgTypeInt8:
+		return d.ext1byte(pos), true
+
+	case cArgTypeInt16:
+		return d.ext2bytes(pos), true
+
+	case cArgTypeInt32:
+		return d.ext4bytes(pos), true
+
+	case cArgTypeInt64:
+		return d.ext8bytes(pos), true
+
+	case cArgTypeUint8:
+		return uint8(d.ext1byte(pos)), true
+
+	case cArgTypeUint16:
+		return uint16(d.ext2bytes(pos)), true
+
+	case cArgTypeUint32:
+		return uint32(d.ext4bytes(pos)), true
+
+	case cArgTypeUint64:
+		return uint64(d.ext8bytes(pos)), true
+
+	case cArgTypeFloat32:
+		vv := d.ext4bytes(pos)
+		return *(*float32)(unsafe.Pointer(&vv)), true
+
+	case cArgTypeFloat64:
+		vv := d.ext8bytes(pos)
+		return *(*float64)(unsafe.Pointer(&vv)), true
+
+	case cArgTypeString:
+		// pos - strlen, pos+1,... - string content
+		return string(d.extNbytes(pos+1, d[pos])), true
+
+	case cArgTypeDigits,
+		cArgTypeHex,
+		cArgTypeAlnum:
+		// pos - number of chars, pos+1,... - bits
+		vv, success := d.extPacked(d[headerPos], pos+1, d[pos])
+		return vv, success
+
+	case cArgTypeVarint:
+		vv, _ := binary.Varint(d[pos:])
+		return vv, true
+
+	case cArgTypeUvarint:
+		vv, _ := binary.Uvarint(d[pos:])
+		return vv, true
+
+	case cArgTypeFalse:
+		return false, true
+
+	case cArgTypeTrue:
+		return true, true
+
+	case cArgTypeBools:
+		// pos - number of bools, pos+1,... - bits
+		return d.extBools(pos+1, d[pos]), true
+
+	case cArgTypeBytes:
+		// pos - len, pos+1,... - content
+		return d.extNbytes(pos+1, d[pos]), true
+
+	case cArgTypeStoredString:
+		if vv, success := d.argStored(pos); success {
+			return string(vv), true
+		}
+		return nil, false
+
+	case cArgTypeStoredBytes:
+		if vv, success := d.argStored(pos); success {
+			return vv, true
+		}
+		return nil, false
+
+	default:
+		return nil, false
+	}
+}
+
 // argNextFromPos returns the next argument's position in d if pos is
 // position of some argument.
 //
@@ -377,13 +610,42 @@ func (d *Encoded) argNextFromPos(pos byte) (nextArgPos byte) {
 
 	case cArgTypeInt64,
 		cArgTypeUint64,
-		cArgTypeFloat64:
+		cArgTypeFloat64,
+		cArgTypeStoredString,
+		cArgTypeStoredBytes:
 		return pos + 9
 
-	case cArgTypeString:
+	case cArgTypeString,
+		cArgTypeBytes:
 		// d[pos] - arg type string, d[pos+1] - len of string
 		return pos + 2 + d[pos+1]
 
+	case cArgTypeFalse,
+		cArgTypeTrue:
+		return pos + 1
+
+	case cArgTypeVarint,
+		cArgTypeUvarint:
+		// Zigzag encoded varint has the same length as uvarint
+		if _, n := binary.Uvarint(d[pos+1:]); n > 0 {
+			return pos + 1 + byte(n)
+		}
+		return cPosErr
+
+	case cArgTypeBools:
+		// d[pos] - arg type bools, d[pos+1] - number of bools
+		return pos + 2 + boolsLen(d[pos+1])
+
+	case cArgTypeDigits,
+		cArgTypeHex,
+		cArgTypeAlnum:
+		// d[pos] - arg type, d[pos+1] - number of chars
+		return pos + 2 + packedLen(d[pos], d[pos+1])
+
+	case cArgTypeKey:
+		// d[pos] - key header, d[pos+1] - key ID, d[pos+2] - argument
+		return d.argNextFromPos(pos + 2)
+
 	default:
 		// THIS IS ERROR SWITCH BRANCH!
 		// DO NOT "PUT" ANY CASES TO THIS BRANCH!
@@ -395,6 +657,71 @@ func (d *Encoded) argNextFromPos(pos byte) (nextArgPos byte) {
 	}
 }
 
+// argEnd returns the next argument's position in d if headerPos is
+// position of some argument's type header (not a key, use argSkipKey).
+// Unlike argNextFromPos, positions are computed in int and argument
+// must end not beyond endPos (that must be not greater than len of d).
+//
+// If type header is unknown, ErrBadArgType is returned.
+// If argument (or its length prefix) ends beyond endPos,
+// ErrBadArgLength is returned.
+// If packed string has char that is not in its alphabet,
+// ErrBadArgValue is returned.
+func (d *Encoded) argEnd(headerPos, endPos int) (nextPos int, err error) {
+
+	argType := d[headerPos]
+	switch argType {
+
+	case cArgTypeString,
+		cArgTypeBytes,
+		cArgTypeBools,
+		cArgTypeDigits,
+		cArgTypeHex,
+		cArgTypeAlnum:
+		// headerPos - arg type, headerPos+1 - length, headerPos+2,... - content
+		if headerPos+1 >= endPos {
+			return 0, ErrBadArgLength
+		}
+		switch argType {
+		case cArgTypeBools:
+			nextPos = headerPos + 2 + int(boolsLen(d[headerPos+1]))
+		case cArgTypeDigits, cArgTypeHex, cArgTypeAlnum:
+			nextPos = headerPos + 2 + int(packedLen(argType, d[headerPos+1]))
+		default:
+			nextPos = headerPos + 2 + int(d[headerPos+1])
+		}
+
+	case cArgTypeVarint,
+		cArgTypeUvarint:
+		// Truncated (n == 0) or overflowed (n < 0) varint
+		_, n := binary.Uvarint(d[headerPos+1 : endPos])
+		if n <= 0 {
+			return 0, ErrBadArgLength
+		}
+		nextPos = headerPos + 1 + n
+
+	default:
+		requiredBytes := d.argNeedForType(argType)
+		if requiredBytes >= cPosMax {
+			return 0, ErrBadArgType
+		}
+		nextPos = headerPos + int(requiredBytes)
+	}
+
+	if nextPos > endPos {
+		return 0, ErrBadArgLength
+	}
+
+	// Packed string's chars must be in its alphabet
+	switch argType {
+	case cArgTypeDigits, cArgTypeHex, cArgTypeAlnum:
+		if _, success := d.extPacked(argType, byte(headerPos+2), d[headerPos+1]); !success {
+			return 0, ErrBadArgValue
+		}
+	}
+	return nextPos, nil
+}
+
 // argType2S returns a string name of type argType.
 func (d *Encoded) argType2S(argType byte) string {
 
@@ -433,6 +760,43 @@ func (d *Encoded) argType2S(argType byte) string {
 	case cArgTypeString:
 		return "string"
 
+	case cArgTypeKey:
+		return "key"
+
+	case cArgTypeVarint:
+		return "varint"
+
+	case cArgTypeUvarint:
+		return "uvarint"
+
+	case cArgTypeFalse,
+		cArgTypeTrue:
+		return "bool"
+
+	case cArgTypeBools:
+		return "bools"
+
+	case cArgTypeBytes:
+		return "bytes"
+
+	case cArgTypeStoredString:
+		return "stored string"
+
+	case cArgTypeStoredBytes:
+		return "stored bytes"
+
+	case cArgTypeExpiry:
+		return "expiry"
+
+	case cArgTypeDigits:
+		return "digits string"
+
+	case cArgTypeHex:
+		return "hex string"
+
+	case cArgTypeAlnum:
+		return "alnum string"
+
 	default:
 		return "UNKNOWN"
 	}
@@ -443,6 +807,18 @@ func (d *Encoded) ArgCount() (num int) {
 	return int(d[cPosArgsCount])
 }
 
+// Free returns the number of bytes that still can be used by arguments
+// in encoded IKB action d, so that it still can be encoded to the Telegram
+// callback data (see CallbackData).
+//
+// Each argument requires 1 byte for type header and N bytes for value
+// (1 byte for string length and its content for strings).
+// If signing (see SetSecret) or encryption (see SetKey) is enabled,
+// the bytes of signature or nonce are reserved.
+func (d *Encoded) Free() (numBytes int) {
+	return int(posFreeMax()) - int(d[cPosArgsFree])
+}
+
 // argCountIncPostfix increases the number of stored arguments in encoded
 // IKB action d and returns the value before increasing.
 //
@@ -453,11 +829,13 @@ func (d *Encoded) argCountIncPostfix() (oldValue int) {
 	return oldValue
 }
 
-// PutArgInt puts int argument v to the encoded IKB action d.
+// PutArgInt puts int argument v to the encoded IKB action d
+// as signed varint (see PutArgVarint).
+//
 // If it was successfully, returns the index of that argument.
 // Otherwise -1 is returned (argument has not been added).
 func (d *Encoded) PutArgInt(v int) (argIdx int) {
-	return d.PutArgInt32(int32(v))
+	return d.PutArgVarint(int64(v))
 }
 
 // PutArgInt8 puts int8 argument v to the encoded IKB action d.
@@ -520,13 +898,13 @@ func (d *Encoded) PutArgInt64(v int64) (argIdx int) {
 	return d.argCountIncPostfix()
 }
 
-// PutArgUint puts uint argument v to the encoded IKB action d.
+// PutArgUint puts uint argument v to the encoded IKB action d
+// as unsigned varint (see PutArgUvarint).
 //
 // If it was successfully, returns the index of that argument.
 // Otherwise -1 is returned (argument has not been added).
 func (d *Encoded) PutArgUint(v uint) (argIdx int) {
-
-	return d.PutArgUint32(uint32(v))
+	return d.PutArgUvarint(uint64(v))
 }
 
 // PutArgUint8 puts uint8 argument v to the encoded IKB action d.
@@ -620,16 +998,18 @@ func (d *Encoded) PutArgFloat64(v float64) (argIdx int) {
 }
 
 // PutArgString puts string argument v to the encoded IKB action d.
+// If v doesn't fit and the store is set, v is kept in the store
+// (see SetStore).
 //
 // If it was successfully, returns the index of that argument.
 // Otherwise -1 is returned (argument has not been added).
 func (d *Encoded) PutArgString(v string) (argIdx int) {
 
 	// String encoding: Arg Type byte, string len byte, string content
-	strlen := byte(len(v))
-	if !d.argHaveFreeBytes(2 + strlen) {
-		return cBadIndex
+	if len(v) > int(cPosFreeMax) || !d.argHaveFreeBytes(2+byte(len(v))) {
+		return d.argPutStored(cArgTypeStoredString, []byte(v))
 	}
+	strlen := byte(len(v))
 
 	// Get start pos, update free index for next argument
 	startPos := d[cPosArgsFree]
@@ -645,18 +1025,20 @@ func (d *Encoded) PutArgString(v string) (argIdx int) {
 }
 
 // GetArgInt extracts int argument from encoded IKB action d,
-// starting search from startIdx argument's index.
+// starting search from startIdx argument's index
+// (negative index counts from the end, -1 is the last argument).
+//
+// Int argument is a signed varint (see PutArgInt), but int32 arguments
+// are extracted too (old encoded IKB actions have int arguments as int32).
 //
 // Returns it and true as success if it is, or zero value and false if error.
 func (d *Encoded) GetArgInt(startIdx int) (v int, success bool) {
-
-	var vv int32
-	vv, success = d.GetArgInt32(startIdx)
-	return int(vv), success
+	return d.argInt(d.argGet(startIdx, cArgTypeVarint, cArgTypeInt32))
 }
 
 // GetArgInt8 extracts int8 argument from encoded IKB action d,
-// starting search from startIdx argument's index.
+// starting search from startIdx argument's index
+// (negative index counts from the end, -1 is the last argument).
 //
 // Returns it and true as success if it is, or zero value and false if error.
 func (d *Encoded) GetArgInt8(startIdx int) (v int8, success bool) {
@@ -669,7 +1051,8 @@ func (d *Encoded) GetArgInt8(startIdx int) (v int8, success bool) {
 }
 
 // GetArgInt16 extracts int16 argument from encoded IKB action d,
-// starting search from startIdx argument's index.
+// starting search from startIdx argument's index
+// (negative index counts from the end, -1 is the last argument).
 //
 // Returns it and true as success if it is, or zero value and false if error.
 func (d *Encoded) GetArgInt16(startIdx int) (v int16, success bool) {
@@ -682,7 +1065,8 @@ func (d *Encoded) GetArgInt16(startIdx int) (v int16, success bool) {
 }
 
 // GetArgInt32 extracts int32 argument from encoded IKB action d,
-// starting search from startIdx argument's index.
+// starting search from startIdx argument's index
+// (negative index counts from the end, -1 is the last argument).
 //
 // Returns it and true as success if it is, or zero value and false if error.
 func (d *Encoded) GetArgInt32(startIdx int) (v int32, success bool) {
@@ -695,7 +1079,8 @@ func (d *Encoded) GetArgInt32(startIdx int) (v int32, success bool) {
 }
 
 // GetArgInt64 extracts int64 argument from encoded IKB action d,
-// starting search from startIdx argument's index.
+// starting search from startIdx argument's index
+// (negative index counts from the end, -1 is the last argument).
 //
 // Returns it and true as success if it is, or zero value and false if error.
 func (d *Encoded) GetArgInt64(startIdx int) (v int64, success bool) {
@@ -708,18 +1093,20 @@ func (d *Encoded) GetArgInt64(startIdx int) (v int64, success bool) {
 }
 
 // GetArgUint extracts uint argument from encoded IKB action d,
-// starting search from startIdx argument's index.
+// starting search from startIdx argument's index
+// (negative index counts from the end, -1 is the last argument).
+//
+// Uint argument is an unsigned varint (see PutArgUint), but uint32 arguments
+// are extracted too (old encoded IKB actions have uint arguments as uint32).
 //
 // Returns it and true as success if it is, or zero value and false if error.
 func (d *Encoded) GetArgUint(startIdx int) (v uint, success bool) {
-
-	var vv uint32
-	vv, success = d.GetArgUint32(startIdx)
-	return uint(vv), success
+	return d.argUint(d.argGet(startIdx, cArgTypeUvarint, cArgTypeUint32))
 }
 
 // GetArgUint8 extracts uint8 argument from encoded IKB action d,
-// starting search from startIdx argument's index.
+// starting search from startIdx argument's index
+// (negative index counts from the end, -1 is the last argument).
 //
 // Returns it and true as success if it is, or zero value and false if error.
 func (d *Encoded) GetArgUint8(startIdx int) (v uint8, success bool) {
@@ -733,7 +1120,8 @@ func (d *Encoded) GetArgUint8(startIdx int) (v uint8, success bool) {
 }
 
 // GetArgUint16 extracts uint16 argument from encoded IKB action d,
-// starting search from startIdx argument's index.
+// starting search from startIdx argument's index
+// (negative index counts from the end, -1 is the last argument).
 //
 // Returns it and true as success if it is, or zero value and false if error.
 func (d *Encoded) GetArgUint16(startIdx int) (v uint16, success bool) {
@@ -747,7 +1135,8 @@ func (d *Encoded) GetArgUint16(startIdx int) (v uint16, success bool) {
 }
 
 // GetArgUint32 extracts uint32 argument from encoded IKB action d,
-// starting search from startIdx argument's index.
+// starting search from startIdx argument's index
+// (negative index counts from the end, -1 is the last argument).
 //
 // Returns it and true as success if it is, or zero value and false if error.
 func (d *Encoded) GetArgUint32(startIdx int) (v uint32, success bool) {
@@ -761,7 +1150,8 @@ func (d *Encoded) GetArgUint32(startIdx int) (v uint32, success bool) {
 }
 
 // GetArgUint64 extracts uint64 argument from encoded IKB action d,
-// starting search from startIdx argument's index.
+// starting search from startIdx argument's index
+// (negative index counts from the end, -1 is the last argument).
 //
 // Returns it and true as success if it is, or zero value and false if error.
 func (d *Encoded) GetArgUint64(startIdx int) (v uint64, success bool) {
@@ -775,7 +1165,8 @@ func (d *Encoded) GetArgUint64(startIdx int) (v uint64, success bool) {
 }
 
 // GetArgFloat32 extracts float32 argument from encoded IKB action d,
-// starting search from startIdx argument's index.
+// starting search from startIdx argument's index
+// (negative index counts from the end, -1 is the last argument).
 //
 // Returns it and true as success if it is, or zero value and false if error.
 func (d *Encoded) GetArgFloat32(startIdx int) (v float32, success bool) {
@@ -789,7 +1180,8 @@ func (d *Encoded) GetArgFloat32(startIdx int) (v float32, success bool) {
 }
 
 // GetArgFloat64 extracts float64 argument from encoded IKB action d,
-// starting search from startIdx argument's index.
+// starting search from startIdx argument's index
+// (negative index counts from the end, -1 is the last argument).
 //
 // Returns it and true as success if it is, or zero value and false if error.
 func (d *Encoded) GetArgFloat64(startIdx int) (v float64, success bool) {
@@ -803,7 +1195,8 @@ func (d *Encoded) GetArgFloat64(startIdx int) (v float64, success bool) {
 }
 
 // GetArgString extracts string argument from encoded IKB action d,
-// starting search from startIdx argument's index.
+// starting search from startIdx argument's index
+// (negative index counts from the end, -1 is the last argument).
 //
 // Returns it and true as success if it is, or zero value and false if error.
 func (d *Encoded) GetArgString(startIdx int) (v string, success bool) {
@@ -814,116 +1207,26 @@ func (d *Encoded) GetArgString(startIdx int) (v string, success bool) {
 		return
 	}
 
-	// startPos - arg type,
-	// startPos + 1 - strlen, startPos + 2,... - string content
-	return string(d.extNbytes(startPos+2, startPos+1)), true
+	// Stored string may be expired
+	vv, success := d.argValue(startPos - 1)
+	if !success {
+		return "", false
+	}
+	return vv.(string), true
 }
 
-// copy returns a copy of the current encoded IKB action d.
-func (d *Encoded) copy() (copy *Encoded) {
+// Clone returns a copy of the current encoded IKB action d.
+// Stored arguments' values (see SetStore) are shared with the copy.
+func (d *Encoded) Clone() *Encoded {
 	copied := *d
 	return &copied
 }
 
-// dump returns a complete debug information about encoded IKB action d.
-// Each slice element represent one entity of encoded IKB action d.
-func (d *Encoded) dump() []EncodedDumpNode {
-
-	// make result slice with length == len of encoded args +
-	// id + ssid + args counter + args free index
-	argCount := d.ArgCount()
-	dumpRes := make([]EncodedDumpNode, argCount+4)
-
-	// Reflect ID
-	dumpRes[0].Type = "Encoded View ID"
-	dumpRes[0].Pos = cPosViewID
-	dumpRes[0].Value = d.GetViewID()
-
-	// Reflect SSID
-	dumpRes[1].Type = "Session ID (SSID)"
-	dumpRes[1].Pos = cPosSessionID
-	dumpRes[1].Value = d.GetSessionID()
-
-	// Reflect args counter
-	dumpRes[2].Type = "Arguments counter"
-	dumpRes[2].Pos = cPosArgsCount
-	dumpRes[2].Value = argCount
-
-	// Reflect args free index
-	dumpRes[3].Type = "Arguments next free position"
-	dumpRes[3].Pos = cPosArgsFree
-	dumpRes[3].Value = d[cPosArgsFree]
-
-	// Save info about arguments
-	pos := cPosArgsContent
-	for i := 0; i < argCount; i++ {
-
-		dumpRes[4+i].Type = "Argument (" + d.argType2S(d[pos]) + ")"
-		dumpRes[4+i].Pos = pos
-		dumpRes[4+i].PosType = pos
-		dumpRes[4+i].TypeHeader = d[pos]
-
-		// Save content position
-		// By default content starts with offset 1
-		// Exceptions: strings
-		switch d[pos] {
-
-		case cArgTypeString:
-			// pos+0 - arg type, pos+1 - strlen, pos+2,... - content
-			dumpRes[4+i].PosContent = pos + 2
-
-		default:
-			dumpRes[4+i].PosContent = pos + 1
-		}
-
-		// Save values
-		// By default content starts with offset 1
-		// Exceptions: strings
-		switch d[pos] {
-
-		case cArgTypeInt8:
-			dumpRes[4+i].Value = d.ext1byte(pos + 1)
-
-		case cArgTypeInt16:
-			dumpRes[4+i].Value = d.ext2bytes(pos + 1)
-
-		case cArgTypeInt32:
-			dumpRes[4+i].Value = d.ext4bytes(pos + 1)
-
-		case cArgTypeInt64:
-			dumpRes[4+i].Value = d.ext8bytes(pos + 1)
-
-		case cArgTypeUint8:
-			dumpRes[4+i].Value = uint8(d.ext1byte(pos + 1))
-
-		case cArgTypeUint16:
-			dumpRes[4+i].Value = uint16(d.ext2bytes(pos + 1))
-
-		case cArgTypeUint32:
-			dumpRes[4+i].Value = uint32(d.ext4bytes(pos + 1))
-
-		case cArgTypeUint64:
-			dumpRes[4+i].Value = uint64(d.ext8bytes(pos + 1))
-
-		case cArgTypeFloat32:
-			v := int32(d.ext4bytes(pos + 1))
-			dumpRes[4+i].Value = *(*float32)(unsafe.Pointer(&v))
-
-		case cArgTypeFloat64:
-			v := int64(d.ext8bytes(pos + 1))
-			dumpRes[4+i].Value = *(*float64)(unsafe.Pointer(&v))
-
-		case cArgTypeString:
-			// pos+1 - strlen, pos+2,... - string content
-			dumpRes[4+i].Value = string(d.extNbytes(pos+2, pos+1))
-
-		default:
-			dumpRes[4+i].Value = nil
-		}
-	}
-
-	// Dump completed
-	return dumpRes
+// Reset resets the current encoded IKB action d to the state of New:
+// view ID, session ID and all arguments are removed.
+func (d *Encoded) Reset() {
+	*d = Encoded{}
+	d.init()
 }
 
 // init initializes the current encoded IKB action object d.
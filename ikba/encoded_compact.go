@@ -0,0 +1,389 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package ikba
+
+import (
+	"encoding/binary"
+)
+
+// Compact arguments.
+//
+// Varint arguments require as many bytes as their value requires
+// (1 byte for -64..63 or 0..127, 2 bytes for -8192..8191 or 0..16383, etc)
+// plus 1 byte for type header. Int and uint arguments are varints.
+//
+// Bool argument requires only 1 byte: the value is the type header itself.
+// Several bools can be packed into one bools argument that requires
+// 1 bit for each bool plus 2 bytes (type header and number of bools).
+//
+// Bytes argument is the same as string argument, but it's []byte.
+
+// argTypeNormalize returns the type header of argument's type
+// for type header argType. Both bool headers (false and true)
+// are cArgTypeFalse, stored arguments have the type of original argument,
+// packed strings are strings.
+func argTypeNormalize(argType byte) byte {
+
+	switch argType {
+
+	case cArgTypeTrue:
+		return cArgTypeFalse
+
+	case cArgTypeStoredString,
+		cArgTypeDigits,
+		cArgTypeHex,
+		cArgTypeAlnum:
+		return cArgTypeString
+
+	case cArgTypeStoredBytes:
+		return cArgTypeBytes
+
+	default:
+		return argType
+	}
+}
+
+// argTypeIs reports whether type header argType is any of argTypes
+// (see argTypeNormalize).
+func argTypeIs(argType byte, argTypes ...byte) bool {
+
+	argType = argTypeNormalize(argType)
+
+	for i := range argTypes {
+		if argType == argTypes[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// boolsLen returns the number of bytes that required to store
+// numBools bit-packed bools.
+func boolsLen(numBools byte) byte {
+	return byte((int(numBools) + 7) / 8)
+}
+
+// extBools extracts numBools bit-packed bools from encoded IKB action d
+// starts from startPos and returns it.
+func (d *Encoded) extBools(startPos, numBools byte) []bool {
+
+	v := make([]bool, numBools)
+	for i := range v {
+		v[i] = d[int(startPos)+i/8]&(1<<uint(i%8)) != 0
+	}
+	return v
+}
+
+// argInt returns the value of signed varint or int32 argument
+// which content starts from startPos as int.
+// If startPos is cPosErr, zero value and false is returned.
+func (d *Encoded) argInt(startPos byte) (v int, success bool) {
+
+	if startPos == cPosErr {
+		return 0, false
+	}
+
+	switch vv, _ := d.argValue(startPos - 1); vv := vv.(type) {
+
+	case int64:
+		return int(vv), true
+
+	case int32:
+		return int(vv), true
+
+	default:
+		return 0, false
+	}
+}
+
+// argUint returns the value of unsigned varint or uint32 argument
+// which content starts from startPos as uint.
+// If startPos is cPosErr, zero value and false is returned.
+func (d *Encoded) argUint(startPos byte) (v uint, success bool) {
+
+	if startPos == cPosErr {
+		return 0, false
+	}
+
+	switch vv, _ := d.argValue(startPos - 1); vv := vv.(type) {
+
+	case uint64:
+		return uint(vv), true
+
+	case uint32:
+		return uint(vv), true
+
+	default:
+		return 0, false
+	}
+}
+
+// argPutVarint puts the varint argument with type header argType
+// and content buf to the encoded IKB action d.
+func (d *Encoded) argPutVarint(argType byte, buf []byte) (argIdx int) {
+
+	if !d.argHaveFreeBytes(1 + byte(len(buf))) {
+		return cBadIndex
+	}
+
+	startPos := d[cPosArgsFree]
+	d[cPosArgsFree] += 1 + byte(len(buf))
+
+	d[startPos] = argType
+	d.putNbytes(startPos+1, buf)
+	return d.argCountIncPostfix()
+}
+
+// PutArgVarint puts int64 argument v to the encoded IKB action d
+// as zigzag encoded signed varint (1-10 bytes depends on v).
+//
+// If it was successfully, returns the index of that argument.
+// Otherwise -1 is returned (argument has not been added).
+func (d *Encoded) PutArgVarint(v int64) (argIdx int) {
+
+	var buf [binary.MaxVarintLen64]byte
+	return d.argPutVarint(cArgTypeVarint, buf[:binary.PutVarint(buf[:], v)])
+}
+
+// PutArgUvarint puts uint64 argument v to the encoded IKB action d
+// as unsigned varint (1-10 bytes depends on v).
+//
+// If it was successfully, returns the index of that argument.
+// Otherwise -1 is returned (argument has not been added).
+func (d *Encoded) PutArgUvarint(v uint64) (argIdx int) {
+
+	var buf [binary.MaxVarintLen64]byte
+	return d.argPutVarint(cArgTypeUvarint, buf[:binary.PutUvarint(buf[:], v)])
+}
+
+// PutArgBool puts bool argument v to the encoded IKB action d.
+// It requires only 1 byte.
+//
+// If it was successfully, returns the index of that argument.
+// Otherwise -1 is returned (argument has not been added).
+func (d *Encoded) PutArgBool(v bool) (argIdx int) {
+
+	argType := cArgTypeFalse
+	if v {
+		argType = cArgTypeTrue
+	}
+
+	if d.argReserveForType(argType) == cPosErr {
+		return cBadIndex
+	}
+	return d.argCountIncPostfix()
+}
+
+// PutArgBools puts bools v to the encoded IKB action d as one
+// bit-packed argument. It requires 2 bytes and 1 bit for each bool.
+// There are 255 bools at most.
+//
+// If it was successfully, returns the index of that argument.
+// Otherwise -1 is returned (argument has not been added).
+func (d *Encoded) PutArgBools(v ...bool) (argIdx int) {
+
+	if len(v) > int(^byte(0)) {
+		return cBadIndex
+	}
+	numBools := byte(len(v))
+	if !d.argHaveFreeBytes(2 + boolsLen(numBools)) {
+		return cBadIndex
+	}
+
+	startPos := d[cPosArgsFree]
+	d[cPosArgsFree] += 2 + boolsLen(numBools)
+
+	d[startPos+0] = cArgTypeBools
+	d[startPos+1] = numBools
+
+	for i := range v {
+		bytePos := int(startPos) + 2 + i/8
+		if v[i] {
+			d[bytePos] |= 1 << uint(i%8)
+		} else {
+			d[bytePos] &^= 1 << uint(i%8)
+		}
+	}
+	return d.argCountIncPostfix()
+}
+
+// PutArgBytes puts bytes argument v to the encoded IKB action d.
+// Like string, it requires 2 bytes and the length of v.
+// If v doesn't fit and the store is set, v is kept in the store
+// (see SetStore).
+//
+// If it was successfully, returns the index of that argument.
+// Otherwise -1 is returned (argument has not been added).
+func (d *Encoded) PutArgBytes(v []byte) (argIdx int) {
+
+	if len(v) > int(cPosFreeMax) || !d.argHaveFreeBytes(2+byte(len(v))) {
+		return d.argPutStored(cArgTypeStoredBytes, v)
+	}
+	length := byte(len(v))
+
+	startPos := d[cPosArgsFree]
+	d[cPosArgsFree] += length + 2
+
+	d[startPos+0] = cArgTypeBytes
+	d[startPos+1] = length
+
+	d.putNbytes(startPos+2, v)
+	return d.argCountIncPostfix()
+}
+
+// GetArgVarint extracts signed varint argument from encoded IKB action d,
+// starting search from startIdx argument's index
+// (negative index counts from the end, -1 is the last argument).
+//
+// Returns it and true as success if it is, or zero value and false if error.
+func (d *Encoded) GetArgVarint(startIdx int) (v int64, success bool) {
+
+	startPos := d.argGet(startIdx, cArgTypeVarint)
+	if startPos == cPosErr {
+		return 0, false
+	}
+	vv, _ := d.argValue(startPos - 1)
+	return vv.(int64), true
+}
+
+// GetArgUvarint extracts unsigned varint argument from encoded IKB action d,
+// starting search from startIdx argument's index
+// (negative index counts from the end, -1 is the last argument).
+//
+// Returns it and true as success if it is, or zero value and false if error.
+func (d *Encoded) GetArgUvarint(startIdx int) (v uint64, success bool) {
+
+	startPos := d.argGet(startIdx, cArgTypeUvarint)
+	if startPos == cPosErr {
+		return 0, false
+	}
+	vv, _ := d.argValue(startPos - 1)
+	return vv.(uint64), true
+}
+
+// GetArgBool extracts bool argument from encoded IKB action d,
+// starting search from startIdx argument's index
+// (negative index counts from the end, -1 is the last argument).
+//
+// Returns it and true as success if it is, or zero value and false if error.
+func (d *Encoded) GetArgBool(startIdx int) (v bool, success bool) {
+
+	startPos := d.argGet(startIdx, cArgTypeFalse)
+	if startPos == cPosErr {
+		return false, false
+	}
+	return d[startPos-1] == cArgTypeTrue, true
+}
+
+// GetArgBools extracts bit-packed bools argument from encoded IKB action d,
+// starting search from startIdx argument's index
+// (negative index counts from the end, -1 is the last argument).
+//
+// Returns it and true as success if it is, or zero value and false if error.
+func (d *Encoded) GetArgBools(startIdx int) (v []bool, success bool) {
+
+	startPos := d.argGet(startIdx, cArgTypeBools)
+	if startPos == cPosErr {
+		return nil, false
+	}
+	vv, _ := d.argValue(startPos - 1)
+	return vv.([]bool), true
+}
+
+// GetArgBytes extracts bytes argument from encoded IKB action d,
+// starting search from startIdx argument's index
+// (negative index counts from the end, -1 is the last argument).
+//
+// Returns it and true as success if it is, or zero value and false if error.
+func (d *Encoded) GetArgBytes(startIdx int) (v []byte, success bool) {
+
+	startPos := d.argGet(startIdx, cArgTypeBytes)
+	if startPos == cPosErr {
+		return nil, false
+	}
+	vv, success := d.argValue(startPos - 1)
+	if !success {
+		return nil, false
+	}
+	return vv.([]byte), true
+}
+
+// GetArgVarintAt extracts signed varint argument with exactly argIdx index
+// from encoded IKB action d (negative index counts from the end).
+//
+// Returns it and true as success if it is, or zero value and false if error
+// (argument is absent or it has another type, use ArgType to know which one).
+func (d *Encoded) GetArgVarintAt(argIdx int) (v int64, success bool) {
+
+	startPos := d.argGetAt(argIdx, cArgTypeVarint)
+	if startPos == cPosErr {
+		return 0, false
+	}
+	vv, _ := d.argValue(startPos - 1)
+	return vv.(int64), true
+}
+
+// GetArgUvarintAt extracts unsigned varint argument with exactly argIdx index
+// from encoded IKB action d (negative index counts from the end).
+//
+// Returns it and true as success if it is, or zero value and false if error
+// (argument is absent or it has another type, use ArgType to know which one).
+func (d *Encoded) GetArgUvarintAt(argIdx int) (v uint64, success bool) {
+
+	startPos := d.argGetAt(argIdx, cArgTypeUvarint)
+	if startPos == cPosErr {
+		return 0, false
+	}
+	vv, _ := d.argValue(startPos - 1)
+	return vv.(uint64), true
+}
+
+// GetArgBoolAt extracts bool argument with exactly argIdx index
+// from encoded IKB action d (negative index counts from the end).
+//
+// Returns it and true as success if it is, or zero value and false if error
+// (argument is absent or it has another type, use ArgType to know which one).
+func (d *Encoded) GetArgBoolAt(argIdx int) (v bool, success bool) {
+
+	startPos := d.argGetAt(argIdx, cArgTypeFalse)
+	if startPos == cPosErr {
+		return false, false
+	}
+	return d[startPos-1] == cArgTypeTrue, true
+}
+
+// GetArgBoolsAt extracts bit-packed bools argument with exactly argIdx index
+// from encoded IKB action d (negative index counts from the end).
+//
+// Returns it and true as success if it is, or zero value and false if error
+// (argument is absent or it has another type, use ArgType to know which one).
+func (d *Encoded) GetArgBoolsAt(argIdx int) (v []bool, success bool) {
+
+	startPos := d.argGetAt(argIdx, cArgTypeBools)
+	if startPos == cPosErr {
+		return nil, false
+	}
+	vv, _ := d.argValue(startPos - 1)
+	return vv.([]bool), true
+}
+
+// GetArgBytesAt extracts bytes argument with exactly argIdx index
+// from encoded IKB action d (negative index counts from the end).
+//
+// Returns it and true as success if it is, or zero value and false if error
+// (argument is absent or it has another type, use ArgType to know which one).
+func (d *Encoded) GetArgBytesAt(argIdx int) (v []byte, success bool) {
+
+	startPos := d.argGetAt(argIdx, cArgTypeBytes)
+	if startPos == cPosErr {
+		return nil, false
+	}
+	vv, success := d.argValue(startPos - 1)
+	if !success {
+		return nil, false
+	}
+	return vv.([]byte), true
+}
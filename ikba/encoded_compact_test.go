@@ -0,0 +1,160 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package ikba
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVarint(t *testing.T) {
+
+	d := New()
+	require.Equal(t, 0, d.PutArgInt(-1))
+	require.Equal(t, int(cPosArgsContent)+2, int(d[cPosArgsFree]))
+	require.Equal(t, 1, d.PutArgInt(math.MaxInt64))
+	require.Equal(t, 2, d.PutArgUint(300))
+	require.Equal(t, 3, d.PutArgVarint(math.MinInt64))
+
+	v, ok := d.GetArgInt(0)
+	require.True(t, ok)
+	require.Equal(t, -1, v)
+
+	v64, ok := d.GetArgVarint(1)
+	require.True(t, ok)
+	require.Equal(t, int64(math.MaxInt64), v64)
+
+	u, ok := d.GetArgUint(0)
+	require.True(t, ok)
+	require.Equal(t, uint(300), u)
+
+	v64, ok = d.GetArgVarintAt(3)
+	require.True(t, ok)
+	require.Equal(t, int64(math.MinInt64), v64)
+
+	require.Equal(t, ArgTypeVarint, d.ArgType(0))
+	require.Equal(t, ArgTypeUvarint, d.ArgType(2))
+
+	decoded, err := Decode(d.CallbackData())
+	require.NoError(t, err)
+	require.Equal(t, d, decoded)
+}
+
+func TestVarintLegacy(t *testing.T) {
+
+	d := New()
+	d.PutArgInt32(-5)
+	d.PutArgUint32(5)
+
+	v, ok := d.GetArgInt(0)
+	require.True(t, ok)
+	require.Equal(t, -5, v)
+
+	v, ok = d.GetArgIntAt(0)
+	require.True(t, ok)
+	require.Equal(t, -5, v)
+
+	u, ok := d.GetArgUintAt(1)
+	require.True(t, ok)
+	require.Equal(t, uint(5), u)
+
+	_, ok = d.GetArgVarint(0)
+	require.False(t, ok)
+}
+
+func TestBool(t *testing.T) {
+
+	d := New()
+	require.Equal(t, 0, d.PutArgBool(true))
+	require.Equal(t, 1, d.PutArgBool(false))
+	require.Equal(t, int(cPosArgsContent)+2, int(d[cPosArgsFree]))
+
+	bools := []bool{true, false, true, true, false, false, false, false, true, true}
+	require.Equal(t, 2, d.PutArgBools(bools...))
+	require.Equal(t, int(cPosArgsContent)+2+2+2, int(d[cPosArgsFree]))
+
+	v, ok := d.GetArgBool(0)
+	require.True(t, ok)
+	require.True(t, v)
+
+	v, ok = d.GetArgBoolAt(1)
+	require.True(t, ok)
+	require.False(t, v)
+
+	vs, ok := d.GetArgBools(0)
+	require.True(t, ok)
+	require.Equal(t, bools, vs)
+
+	require.Equal(t, ArgTypeBool, d.ArgType(0))
+	require.Equal(t, ArgTypeBool, d.ArgType(1))
+	require.Equal(t, ArgTypeBools, d.ArgType(2))
+
+	decoded, err := Decode(d.CallbackData())
+	require.NoError(t, err)
+	require.Equal(t, d, decoded)
+}
+
+func TestBytes(t *testing.T) {
+
+	d := New()
+	require.Equal(t, 0, d.PutArgBytes([]byte{0, 1, 255}))
+	require.Equal(t, cBadIndex, d.PutArgBytes(make([]byte, 40)))
+
+	v, ok := d.GetArgBytesAt(0)
+	require.True(t, ok)
+	require.Equal(t, []byte{0, 1, 255}, v)
+
+	_, ok = d.GetArgStringAt(0)
+	require.False(t, ok)
+
+	require.Equal(t, `{view:0 ssid:0 args:[bytes([0 1 255])]}`, d.String())
+
+	decoded, err := Decode(d.CallbackData())
+	require.NoError(t, err)
+	require.Equal(t, d, decoded)
+}
+
+func TestCompactMalformed(t *testing.T) {
+
+	tests := []struct {
+		name string
+		args []byte
+		err  error
+	}{
+		{"truncated varint", []byte{1, 12, cArgTypeVarint, 0x80}, ErrBadArgLength},
+		{"truncated bools", []byte{1, 13, cArgTypeBools, 9, 1}, ErrBadArgLength},
+		{"truncated bytes", []byte{1, 13, cArgTypeBytes, 2, 1}, ErrBadArgLength},
+		{"bool", []byte{1, 11, cArgTypeTrue}, nil},
+	}
+
+	for _, test := range tests {
+		var d Encoded
+		copy(d[cPosArgs:], test.args)
+		require.Equal(t, test.err, d.validate(), test.name)
+	}
+}
+
+func TestMarshalCompact(t *testing.T) {
+
+	type item struct {
+		ID    int    `ikba:"0"`
+		Flag  bool   `ikba:"1"`
+		Flags []bool `ikba:"2"`
+		Raw   []byte `ikba:"3"`
+	}
+
+	v := item{ID: -3, Flag: true, Flags: []bool{false, true}, Raw: []byte("x")}
+	d, err := Marshal(v)
+	require.NoError(t, err)
+	require.Equal(t, ArgTypeVarint, d.ArgType(0))
+	require.Equal(t, ArgTypeBool, d.ArgType(1))
+
+	var decoded item
+	require.NoError(t, Unmarshal(d, &decoded))
+	require.Equal(t, v, decoded)
+}
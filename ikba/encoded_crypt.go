@@ -0,0 +1,177 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package ikba
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"sync"
+)
+
+// Encrypted encoded IKB actions.
+//
+// Arguments of encoded IKB action (database IDs, internal state, etc)
+// are visible to anyone who inspects callback data.
+// If the bot's key is set (see SetKey), the arguments' part
+// (starting from cPosArgs: arguments' header and content) is encrypted
+// by CallbackData using AES-256 in CTR mode and synthetic nonce
+// is appended to it:
+// < View ID, Session ID : 8 bytes > < Encrypted arguments : N bytes >
+// < Nonce : 4 bytes >
+//
+// The initialization vector is view ID, session ID and nonce,
+// and encryption doesn't increase the length of arguments. Only nonce
+// requires 4 bytes, thus arguments of encrypted encoded IKB action
+// can use 4 bytes less (see Free).
+//
+// The nonce is not random: it's truncated HMAC-SHA256 of the whole
+// plaintext (SIV-like construction) with the key derived from the bot's key.
+// Thus:
+// - the same encoded IKB action is always encrypted to the same
+// callback data (equal buttons are recognisable as equal);
+// - different encoded IKB actions share the key stream only if they have
+// the same view ID, session ID and 32-bit nonce (it's likely after about
+// 2^16 different encoded IKB actions of the same view and session, use
+// sessions to keep their number low);
+// - tampered encrypted arguments are detected: decrypted plaintext doesn't
+// match the nonce, Decode returns ErrBadCiphertext (the chance of
+// successful forgery is 2^-32).
+//
+// Signing (see SetSecret) may be enabled too: the signature is computed
+// over encrypted data.
+
+// ErrBadCiphertext means that encrypted arguments of callback data
+// have been tampered (or encrypted by another key): decrypted encoded
+// IKB action doesn't match its nonce.
+var ErrBadCiphertext = errors.New("ikba: bad ciphertext of encoded IKB action")
+
+// Predefined constants of encrypted encoded IKB action.
+const (
+
+	// Length of the encrypted encoded IKB action's nonce in bytes.
+	// The nonce is synthetic, it's not a counter (see the birthday bound
+	// in the notes above).
+	cNonceLen byte = 4
+)
+
+// encryption is the cipher arguments of encoded IKB actions are encrypted by
+// and the key of synthetic nonces. Encryption is disabled if block is nil.
+var encryption = struct {
+	sync.RWMutex
+	block    cipher.Block
+	nonceKey []byte
+}{}
+
+// SetKey sets the bot's key that arguments of encoded IKB actions
+// are encrypted and decrypted with. Empty key disables encryption.
+//
+// Key may have any length, AES-256 key is derived from it by SHA-256
+// (and the key of synthetic nonces is derived by HMAC-SHA256).
+//
+// Call it once at startup before any encoded IKB action is created:
+// nonce changes the number of bytes arguments can use,
+// and callback data that has been encrypted by the old key
+// (or has not been encrypted) can't be decoded anymore.
+func SetKey(key []byte) {
+
+	var (
+		block    cipher.Block
+		nonceKey []byte
+	)
+	if len(key) != 0 {
+		sum := sha256.Sum256(key)
+		// Can't fail: the length of key is always 32
+		block, _ = aes.NewCipher(sum[:])
+
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte("ikba nonce"))
+		nonceKey = mac.Sum(nil)
+	}
+
+	encryption.Lock()
+	defer encryption.Unlock()
+
+	encryption.block, encryption.nonceKey = block, nonceKey
+}
+
+// nonceLen returns the number of bytes the nonce requires,
+// or 0 if encryption is disabled.
+func nonceLen() byte {
+
+	encryption.RLock()
+	defer encryption.RUnlock()
+
+	if encryption.block == nil {
+		return 0
+	}
+	return cNonceLen
+}
+
+// cryptStream returns the CTR stream of block for the data that starts
+// from view ID and session ID (header) and has been encrypted with nonce.
+func cryptStream(block cipher.Block, header, nonce []byte) cipher.Stream {
+
+	iv := make([]byte, aes.BlockSize)
+	copy(iv, header[:cPosArgs])
+	copy(iv[cPosArgs:], nonce)
+	return cipher.NewCTR(block, iv)
+}
+
+// syntheticNonce returns the nonce of plaintext data (view ID, session ID
+// and arguments): truncated HMAC-SHA256 of data.
+func syntheticNonce(nonceKey, data []byte) []byte {
+
+	mac := hmac.New(sha256.New, nonceKey)
+	mac.Write(data)
+	return mac.Sum(nil)[:cNonceLen]
+}
+
+// encrypt encrypts the arguments' part of data, appends the nonce to data
+// and returns it. If encryption is disabled, data is returned as is.
+func encrypt(data []byte) []byte {
+
+	encryption.RLock()
+	defer encryption.RUnlock()
+
+	if encryption.block == nil {
+		return data
+	}
+
+	nonce := syntheticNonce(encryption.nonceKey, data)
+
+	cryptStream(encryption.block, data, nonce).
+		XORKeyStream(data[cPosArgs:], data[cPosArgs:])
+	return append(data, nonce...)
+}
+
+// decrypt extracts the nonce from the end of encrypted data, decrypts
+// the arguments' part of data and returns it without nonce.
+// If encryption is disabled, encrypted is returned as is.
+//
+// If decrypted data doesn't match the nonce, ErrBadCiphertext is returned.
+// Encrypted must have at least view ID, session ID and nonce.
+func decrypt(encrypted []byte) ([]byte, error) {
+
+	encryption.RLock()
+	defer encryption.RUnlock()
+
+	if encryption.block == nil {
+		return encrypted, nil
+	}
+
+	data, nonce := encrypted[:len(encrypted)-int(cNonceLen)], encrypted[len(encrypted)-int(cNonceLen):]
+
+	cryptStream(encryption.block, data, nonce).
+		XORKeyStream(data[cPosArgs:], data[cPosArgs:])
+
+	if !hmac.Equal(nonce, syntheticNonce(encryption.nonceKey, data)) {
+		return nil, ErrBadCiphertext
+	}
+	return data, nil
+}
@@ -0,0 +1,91 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package ikba
+
+import (
+	"bytes"
+	"encoding/ascii85"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptedDecode(t *testing.T) {
+
+	SetKey([]byte("key"))
+	defer SetKey(nil)
+
+	d := New()
+	d.PutViewID(42)
+	require.Equal(t, int(cPosFreeMax-cPosArgsContent-cNonceLen), d.Free())
+	require.NotEqual(t, cBadIndex, d.PutArgInt64(1<<40))
+	require.NotEqual(t, cBadIndex, d.PutArgString("internal"))
+
+	// Nonce is synthetic: the same action, the same callback data
+	callbackData := d.CallbackData()
+	require.Equal(t, callbackData, d.CallbackData())
+
+	other := d.Clone()
+	other.PutArgInt8(1)
+	require.NotEqual(t, callbackData, other.CallbackData())
+
+	decoded, err := Decode(callbackData)
+	require.NoError(t, err)
+	require.Equal(t, d, decoded)
+
+	// Arguments are not visible, view ID is
+	raw := make([]byte, 4*len(callbackData))
+	n, _, err := ascii85.Decode(raw, []byte(callbackData[1:]), true)
+	require.NoError(t, err)
+	require.Equal(t, int(d[cPosArgsFree]+cNonceLen), n)
+	require.False(t, bytes.Contains(raw[:n], []byte("internal")))
+	require.Equal(t, d[:cPosArgs], raw[:cPosArgs])
+}
+
+func TestEncryptedTampered(t *testing.T) {
+
+	SetKey([]byte("key"))
+	defer SetKey(nil)
+
+	d := New()
+	d.PutArgInt64(1 << 40)
+
+	raw := make([]byte, cCallbackDataMax*4)
+	n, _, err := ascii85.Decode(raw, []byte(d.CallbackData()[1:]), true)
+	require.NoError(t, err)
+
+	// Flip a bit of the encrypted argument (malleable without nonce check)
+	raw[cPosArgsContent+1] ^= 1
+	buf := make([]byte, ascii85.MaxEncodedLen(n))
+	tampered := "w" + string(buf[:ascii85.Encode(buf, raw[:n])])
+
+	_, err = Decode(tampered)
+	require.Equal(t, ErrBadCiphertext, err)
+
+	// Wrong key
+	callbackData := d.CallbackData()
+	SetKey([]byte("another key"))
+	_, err = Decode(callbackData)
+	require.Equal(t, ErrBadCiphertext, err)
+}
+
+func TestEncryptedSignedDecode(t *testing.T) {
+
+	SetKey([]byte("key"))
+	SetSecret([]byte("secret"))
+	defer SetKey(nil)
+	defer SetSecret(nil)
+
+	d := New()
+	require.Equal(t, int(cPosFreeMax-cPosArgsContent-cNonceLen-cSignatureLen), d.Free())
+	for d.PutArgInt8(1) != cBadIndex {
+	}
+	require.True(t, len(d.CallbackData()) <= cCallbackDataMax)
+
+	decoded, err := Decode(d.CallbackData())
+	require.NoError(t, err)
+	require.Equal(t, d, decoded)
+}
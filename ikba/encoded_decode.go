@@ -0,0 +1,236 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package ikba
+
+import (
+	"encoding/ascii85"
+	"errors"
+)
+
+// Errors that can be returned by Decode.
+var (
+
+	// ErrBadLength means that callback data is too short to be
+	// an encoded IKB action (no place for view ID, session ID, and
+	// arguments' header) or too long for it.
+	ErrBadLength = errors.New("ikba: bad length of encoded IKB action")
+
+	// ErrBadEncoding means that callback data is not a valid text encoding
+	// of encoded IKB action (it has chars that can't be generated by
+	// CallbackData method).
+	ErrBadEncoding = errors.New("ikba: bad text encoding of encoded IKB action")
+
+	// ErrBadLayout means that callback data has the right length,
+	// but its arguments' header is not consistent with it
+	// (it's a malformed or foreign callback data).
+	ErrBadLayout = errors.New("ikba: bad layout of encoded IKB action")
+
+	// ErrBadArgType means that some encoded argument has unknown type header
+	// (or a malformed key prefix).
+	ErrBadArgType = errors.New("ikba: bad type of encoded argument")
+
+	// ErrBadArgLength means that some encoded argument (or its string
+	// length prefix) goes beyond the used bytes of encoded IKB action.
+	ErrBadArgLength = errors.New("ikba: bad length of encoded argument")
+
+	// ErrBadArgCount means that arguments' counter doesn't match
+	// the number of actually encoded arguments.
+	ErrBadArgCount = errors.New("ikba: bad count of encoded arguments")
+
+	// ErrBadArgValue means that some encoded argument has a value that
+	// can't be encoded (packed string's char that is not in its alphabet).
+	ErrBadArgValue = errors.New("ikba: bad value of encoded argument")
+)
+
+// New creates a new empty encoded IKB action object and initializes it.
+// Use Put* methods to fill it.
+func New() *Encoded {
+	var d Encoded
+	d.init()
+	return &d
+}
+
+// CallbackData returns the encoded IKB action d as string that can be used
+// as Telegram inline keyboard button's callback data.
+//
+// Only used bytes are encoded (up to the next free argument's position)
+// by Ascii85, so callback data is a valid UTF-8 (ASCII) string
+// that is never longer than 64 bytes (see Free).
+// If encryption is enabled (see SetKey), arguments are encrypted
+// and the nonce is encoded too.
+// If signing is enabled (see SetSecret), the signature is encoded too.
+//
+// Callback data starts from the format version marker (see Version).
+func (d *Encoded) CallbackData() string {
+
+	data := sign(encrypt(append([]byte(nil), d[:d[cPosArgsFree]]...)))
+
+	buf := make([]byte, 1+ascii85.MaxEncodedLen(len(data)))
+
+	// Version 1 has the same layout, thus IKB action that uses more bytes
+	// than the current version allows (it has been decoded from version 1
+	// callback data) is encoded as version 1
+	if len(data) > int(cPosFreeMax) {
+		return string(buf[1 : 1+ascii85.Encode(buf[1:], data)])
+	}
+
+	buf[0] = versionMarker(Version)
+	return string(buf[:1+ascii85.Encode(buf[1:], data)])
+}
+
+// encodedLen returns the max length of callback data that numBytes
+// used bytes of Encoded are encoded to.
+//
+// Ascii85 encodes each 4 bytes to 5 chars and the tail of 1-3 bytes
+// to the tail of 2-4 chars. Zero groups of 4 bytes are encoded
+// to 1 char, but it's not guaranteed.
+func encodedLen(numBytes int) int {
+
+	n := numBytes / 4 * 5
+	if tail := numBytes % 4; tail != 0 {
+		n += tail + 1
+	}
+	return n
+}
+
+// Decode creates a new encoded IKB action object from callback data s
+// (that has been generated by CallbackData method) and returns it.
+//
+// Callback data is an untrusted input (any Telegram client can send
+// any callback data), thus decoded IKB action is validated completely:
+// each argument must have known type header, each argument must end not
+// beyond the used bytes and arguments' counter must match the number of
+// actually encoded arguments.
+//
+// Decoded IKB action is a copy and it doesn't refer to s.
+// It's safe to call Decode with any (malformed or untrusted) data;
+// ErrBadLength, ErrBadEncoding, ErrBadSignature, ErrBadCiphertext,
+// ErrBadLayout or ErrBadArg* is returned in that case.
+//
+// If the deadline of decoded IKB action has passed (see SetExpiry),
+// ErrActionExpired is returned.
+// If decoded IKB action has stored argument which value has expired
+// (see SetStore), ErrExpired is returned. If the store fails,
+// its error is returned as is.
+// If callback data has unknown format version, ErrUnknownVersion is returned.
+func Decode(s string) (*Encoded, error) {
+
+	if len(s) == 0 || len(s) > cCallbackDataMax {
+		return nil, ErrBadLength
+	}
+
+	// Version 1 has the same layout, but 1 byte more for arguments
+	var posFreeMax byte
+	version, s := versionOf(s)
+	switch version {
+
+	case cVersionLegacy:
+		posFreeMax = cPosFreeMaxLegacy
+
+	case cVersionMarked, Version:
+		posFreeMax = cPosFreeMax
+
+	default:
+		return nil, ErrUnknownVersion
+	}
+
+	// Each char may be decoded to 4 bytes at most ('z' is 4 zero bytes)
+	buf := make([]byte, 4*len(s)+4)
+	n, _, err := ascii85.Decode(buf, []byte(s), true)
+	if err != nil {
+		return nil, ErrBadEncoding
+	}
+
+	// Decoded data must have at least view ID, session ID, arguments' header
+	// (and nonce, signature if encryption, signing are enabled) and can't
+	// be longer than the max allowable next free position
+	if n < int(cPosArgsContent+nonceLen()+signatureLen()) || n > int(posFreeMax) {
+		return nil, ErrBadLength
+	}
+
+	// Signature is checked before any other check of the content
+	data, err := verify(buf[:n])
+	if err != nil {
+		return nil, err
+	}
+	if data, err = decrypt(data); err != nil {
+		return nil, err
+	}
+
+	var d Encoded
+	copy(d[:], data)
+
+	// Next free position must point right after the last used byte
+	if int(d[cPosArgsFree]) != len(data) {
+		return nil, ErrBadLayout
+	}
+
+	if err := d.validate(); err != nil {
+		return nil, err
+	}
+
+	if d.Expired() {
+		return nil, ErrActionExpired
+	}
+
+	if err := d.checkStored(); err != nil {
+		return nil, err
+	}
+
+	return &d, nil
+}
+
+// validate walks all encoded arguments of d and checks that each argument
+// has known type header, that each argument (and string content) ends
+// not beyond the next free position and that the number of arguments
+// matches arguments' counter.
+//
+// The length of each argument is computed in int, thus neither
+// malformed length prefix, nor position overflow can't cause
+// an infinite loop or out of range access.
+//
+// Returns nil if d is valid or one of ErrBadArg* errors.
+func (d *Encoded) validate() error {
+
+	var (
+		freePos  = int(d[cPosArgsFree])
+		argCount = 0
+	)
+
+	// Expiry (if it's set) is not an argument and it's not counted
+	pos, err := d.expiryEnd(freePos)
+	if err != nil {
+		return err
+	}
+
+	for pos < freePos {
+
+		// Key prefix: key ID must be not zero and must be followed by argument
+		if d[pos] == cArgTypeKey {
+			if pos+2 >= freePos {
+				return ErrBadArgLength
+			}
+			if d[pos+1] == 0 || d[pos+2] == cArgTypeKey {
+				return ErrBadArgType
+			}
+			pos += 2
+		}
+
+		nextPos, err := d.argEnd(pos, freePos)
+		if err != nil {
+			return err
+		}
+
+		pos = nextPos
+		argCount++
+	}
+
+	if argCount != d.ArgCount() {
+		return ErrBadArgCount
+	}
+
+	return nil
+}
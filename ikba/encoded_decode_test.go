@@ -0,0 +1,77 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package ikba
+
+import (
+	"encoding/ascii85"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// encodeRaw returns callback data for RAW encoded IKB action's bytes raw
+// without any checks.
+func encodeRaw(raw ...byte) string {
+	buf := make([]byte, ascii85.MaxEncodedLen(len(raw)))
+	return string(buf[:ascii85.Encode(buf, raw)])
+}
+
+func TestDecodeValidation(t *testing.T) {
+
+	header := []byte{0, 0, 0, 0, 0, 0, 0, 0}
+
+	tests := []struct {
+		name string
+		args []byte
+		err  error
+	}{
+		{"empty", []byte{0, 10}, nil},
+		{"int8", []byte{1, 12, cArgTypeInt8, 1}, nil},
+		{"string", []byte{1, 14, cArgTypeString, 2, 'h', 'i'}, nil},
+		{"named", []byte{1, 14, cArgTypeKey, 1, cArgTypeInt8, 1}, nil},
+		{"unknown type", []byte{1, 12, 99, 1}, ErrBadArgType},
+		{"zero type", []byte{1, 12, 0, 1}, ErrBadArgType},
+		{"truncated int32", []byte{1, 14, cArgTypeInt32, 1, 2, 3}, ErrBadArgLength},
+		{"long string", []byte{1, 14, cArgTypeString, 250, 'h', 'i'}, ErrBadArgLength},
+		{"no string length", []byte{1, 11, cArgTypeString}, ErrBadArgLength},
+		{"more args", []byte{1, 14, cArgTypeInt8, 1, cArgTypeInt8, 2}, ErrBadArgCount},
+		{"less args", []byte{3, 14, cArgTypeInt8, 1, cArgTypeInt8, 2}, ErrBadArgCount},
+		{"zero key", []byte{1, 14, cArgTypeKey, 0, cArgTypeInt8, 1}, ErrBadArgType},
+		{"key of key", []byte{1, 14, cArgTypeKey, 1, cArgTypeKey, 1}, ErrBadArgType},
+		{"key only", []byte{1, 12, cArgTypeKey, 1}, ErrBadArgLength},
+		{"bad free", []byte{0, 12, 0}, ErrBadLayout},
+	}
+
+	for _, test := range tests {
+		raw := append(append([]byte{}, header...), test.args...)
+		_, err := Decode(encodeRaw(raw...))
+		require.Equal(t, test.err, err, test.name)
+	}
+}
+
+func TestDecodeCrafted(t *testing.T) {
+
+	header := []byte{1, 0, 0, 0, 1, 0, 0, 0}
+
+	// String length goes far beyond the used bytes
+	raw := append(append([]byte{}, header...), 1, 24, cArgTypeString, 255)
+	raw = append(raw, make([]byte, 12)...)
+	_, err := Decode(encodeRaw(raw...))
+	require.Equal(t, ErrBadArgLength, err)
+
+	// Empty string right at the end of the used bytes
+	raw = append(append([]byte{}, header...), 5, 48)
+	for i := 0; i < 4; i++ {
+		raw = append(raw, cArgTypeInt64, 0, 0, 0, 0, 0, 0, 0, 0)
+	}
+	raw = append(raw, cArgTypeString, 0)
+
+	d, err := Decode(encodeRaw(raw...))
+	require.NoError(t, err)
+	v, ok := d.GetArgString(0)
+	require.True(t, ok)
+	require.Equal(t, "", v)
+}
@@ -0,0 +1,262 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package ikba
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dump returns a complete debug information about encoded IKB action d.
+// Each slice element represent one entity of encoded IKB action d:
+// view ID, session ID, arguments' counter, arguments' next free position,
+// expiry (if it's set, see SetExpiry) and then each encoded argument.
+//
+// Dump never panics, even if d is malformed. Malformed node (unknown type
+// header, truncated argument, wrong arguments' counter or next free
+// position, expired stored argument) has not empty Error field.
+// Arguments after unknown or truncated argument can't be found,
+// thus such node is always the last one.
+func (d *Encoded) Dump() []EncodedDumpNode {
+
+	// make result slice with capacity == len of encoded args +
+	// id + ssid + args counter + args free index
+	argCount := d.ArgCount()
+	dumpRes := make([]EncodedDumpNode, 4, 4+argCount)
+
+	// Reflect ID
+	dumpRes[0].Type = "Encoded View ID"
+	dumpRes[0].Pos = cPosViewID
+	dumpRes[0].Value = d.GetViewID()
+
+	// Reflect SSID
+	dumpRes[1].Type = "Session ID (SSID)"
+	dumpRes[1].Pos = cPosSessionID
+	dumpRes[1].Value = d.GetSessionID()
+
+	// Reflect args counter
+	dumpRes[2].Type = "Arguments counter"
+	dumpRes[2].Pos = cPosArgsCount
+	dumpRes[2].Value = argCount
+
+	// Reflect args free index
+	dumpRes[3].Type = "Arguments next free position"
+	dumpRes[3].Pos = cPosArgsFree
+	dumpRes[3].Value = d[cPosArgsFree]
+
+	// Arguments are dumped only up to the next free position,
+	// but it can be malformed too
+	freePos := int(d[cPosArgsFree])
+	switch {
+
+	case freePos < int(cPosArgsContent):
+		dumpRes[3].Error = "points to arguments' header"
+		freePos = int(cPosArgsContent)
+
+	case freePos > len(d):
+		dumpRes[3].Error = "points out of encoded IKB action"
+		freePos = len(d)
+	}
+
+	// Expiry (if it's set) is placed right before the first argument
+	pos, malformed, hasExpiry := int(cPosArgsContent), false, false
+	if pos < freePos && d[pos] == cArgTypeExpiry {
+
+		var node EncodedDumpNode
+		node, pos = d.dumpExpiry(freePos)
+
+		dumpRes = append(dumpRes, node)
+		malformed, hasExpiry = node.Error != "", true
+	}
+
+	// Save info about arguments
+	for pos < freePos {
+
+		var node EncodedDumpNode
+		node, pos = d.dumpArg(pos, freePos)
+
+		dumpRes = append(dumpRes, node)
+		malformed = malformed || node.Error != ""
+	}
+
+	num := len(dumpRes) - 4
+	if hasExpiry {
+		num--
+	}
+	if !malformed && num != argCount {
+		dumpRes[2].Error = "doesn't match " + strconv.Itoa(num) + " encoded arguments"
+	}
+
+	// Dump completed
+	return dumpRes
+}
+
+// dumpExpiry returns the dump node of expiry (see SetExpiry)
+// and the position of the first argument.
+//
+// Expiry must end not beyond freePos (that must be not greater than
+// len of d), otherwise node's Error field is not empty and first
+// argument's position is undefined.
+func (d *Encoded) dumpExpiry(freePos int) (node EncodedDumpNode, nextPos int) {
+
+	node.Type = "Expiry"
+	node.Pos = cPosArgsContent
+	node.PosType = cPosArgsContent
+	node.PosContent = cPosArgsContent + 1
+	node.TypeHeader = cArgTypeExpiry
+
+	nextPos, err := d.expiryEnd(freePos)
+	if err != nil {
+		node.Error = "truncated expiry"
+		return node, freePos
+	}
+
+	node.Value, _ = d.Expiry()
+	return node, nextPos
+}
+
+// dumpArg returns the dump node of argument that starts from pos
+// and the position of the next argument.
+//
+// Argument must end not beyond freePos (that must be not greater than
+// len of d), otherwise node's Error field is not empty and next
+// argument's position is undefined.
+func (d *Encoded) dumpArg(pos, freePos int) (node EncodedDumpNode, nextPos int) {
+
+	node.Type = "Argument"
+	node.Pos = byte(pos)
+
+	// Named argument: pos - key header, pos+1 - key ID, pos+2 - argument
+	headerPos := pos
+	if d[pos] == cArgTypeKey {
+		node.Type = "Named argument"
+		if pos+2 >= freePos {
+			node.Error = "truncated key"
+			return node, freePos
+		}
+		node.Key = d.keyName(d[pos+1])
+		headerPos = pos + 2
+	}
+
+	argType := d[headerPos]
+	node.PosType = byte(headerPos)
+	node.PosContent = byte(headerPos + 1)
+	node.TypeHeader = argType
+
+	if argType == cArgTypeString || argType == cArgTypeBytes || argType == cArgTypeBools ||
+		argType == cArgTypeDigits || argType == cArgTypeHex || argType == cArgTypeAlnum {
+		// headerPos - arg type, headerPos+1 - length, headerPos+2,... - content
+		node.PosContent = byte(headerPos + 2)
+	}
+
+	nextPos, err := d.argEnd(headerPos, freePos)
+	switch {
+
+	case err == ErrBadArgType:
+		node.Error = "unknown type header " + strconv.Itoa(int(argType))
+		return node, freePos
+
+	case err == ErrBadArgValue:
+		node.Error = "char is not in the alphabet"
+
+	case err != nil && argType == cArgTypeString && headerPos+1 < freePos:
+		node.Value = string(d[headerPos+2 : freePos])
+		node.Error = "truncated string (" + strconv.Itoa(freePos-headerPos-2) +
+			" of " + strconv.Itoa(int(d[headerPos+1])) + " bytes)"
+
+	case err != nil && argType == cArgTypeString:
+		node.Error = "truncated string length"
+
+	case err != nil:
+		node.Error = "truncated value"
+	}
+
+	// Arguments after truncated argument can't be found
+	if err != nil {
+		nextPos = freePos
+	}
+
+	node.Type += " (" + d.argType2S(argType)
+	if node.Key != "" {
+		node.Type += ", key " + node.Key
+	}
+	node.Type += ")"
+
+	// Save value (partial string is already saved)
+	if node.Error == "" {
+		var ok bool
+		if node.Value, ok = d.argValue(byte(headerPos)); !ok {
+			node.Error = "stored value is not available"
+		}
+	}
+
+	return node, nextPos
+}
+
+// DumpJSON returns JSON representation of Dump of encoded IKB action d.
+func (d *Encoded) DumpJSON() ([]byte, error) {
+	return json.Marshal(d.Dump())
+}
+
+// String returns a human readable representation of encoded IKB action d
+// that is useful for logs. For example:
+// {view:42 ssid:7 args:[int32(100) item=string("foo")]}
+// or, if expiry is set (see SetExpiry):
+// {view:42 ssid:7 expires:2019-06-01T12:00:00Z args:[int32(100)]}
+//
+// Malformed parts are reported too (see Dump).
+func (d *Encoded) String() string {
+
+	var (
+		b     strings.Builder
+		nodes = d.Dump()
+	)
+
+	fmt.Fprintf(&b, "{view:%d ssid:%d", nodes[0].Value, nodes[1].Value)
+
+	// Expiry node (if any) is the first one after the header nodes
+	args := nodes[4:]
+	if len(args) != 0 && args[0].Type == "Expiry" {
+		if args[0].Error != "" {
+			fmt.Fprintf(&b, " expires:!(%s)", args[0].Error)
+		} else {
+			fmt.Fprintf(&b, " expires:%s", args[0].Value.(time.Time).Format(time.RFC3339))
+		}
+		args = args[1:]
+	}
+	b.WriteString(" args:[")
+
+	for i, node := range args {
+		if i != 0 {
+			b.WriteByte(' ')
+		}
+		if node.Key != "" {
+			b.WriteString(node.Key + "=")
+		}
+		if node.Error != "" {
+			fmt.Fprintf(&b, "!(%s)", node.Error)
+			continue
+		}
+		if v, ok := node.Value.(string); ok {
+			fmt.Fprintf(&b, "%s(%q)", d.argType2S(node.TypeHeader), v)
+		} else {
+			fmt.Fprintf(&b, "%s(%v)", d.argType2S(node.TypeHeader), node.Value)
+		}
+	}
+	b.WriteByte(']')
+
+	for _, node := range nodes[2:4] {
+		if node.Error != "" {
+			fmt.Fprintf(&b, " !(%s %v: %s)", strings.ToLower(node.Type), node.Value, node.Error)
+		}
+	}
+	b.WriteByte('}')
+
+	return b.String()
+}
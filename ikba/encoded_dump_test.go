@@ -0,0 +1,105 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package ikba
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDump(t *testing.T) {
+
+	d := New()
+	d.PutViewID(42)
+	d.PutSessionID(7)
+	d.PutArgInt32(100)
+	d.PutArgString("foo")
+
+	nodes := d.Dump()
+	require.Len(t, nodes, 6)
+	for _, node := range nodes {
+		require.Empty(t, node.Error)
+	}
+	require.Equal(t, "Argument (int32)", nodes[4].Type)
+	require.Equal(t, int32(100), nodes[4].Value)
+	require.Equal(t, "foo", nodes[5].Value)
+	require.Equal(t, cPosArgsContent+5+2, nodes[5].PosContent)
+
+	require.Equal(t, `{view:42 ssid:7 args:[int32(100) string("foo")]}`, d.String())
+}
+
+func TestDumpMalformed(t *testing.T) {
+
+	tests := []struct {
+		name string
+		args []byte
+		str  string
+	}{
+		{"unknown type", []byte{2, 14, cArgTypeInt8, 1, 99, 1},
+			`{view:0 ssid:0 args:[int8(1) !(unknown type header 99)]}`},
+		{"truncated string", []byte{1, 14, cArgTypeString, 10, 'h', 'i'},
+			`{view:0 ssid:0 args:[!(truncated string (2 of 10 bytes))]}`},
+		{"truncated value", []byte{1, 13, cArgTypeInt64, 1, 2},
+			`{view:0 ssid:0 args:[!(truncated value)]}`},
+		{"truncated key", []byte{1, 12, cArgTypeKey, 1},
+			`{view:0 ssid:0 args:[!(truncated key)]}`},
+		{"bad counter", []byte{3, 12, cArgTypeInt8, 1},
+			`{view:0 ssid:0 args:[int8(1)] !(arguments counter 3: doesn't match 1 encoded arguments)}`},
+		{"bad free", []byte{0, 5},
+			`{view:0 ssid:0 args:[] !(arguments next free position 5: points to arguments' header)}`},
+	}
+
+	for _, test := range tests {
+		var d Encoded
+		copy(d[cPosArgs:], test.args)
+		require.Equal(t, test.str, d.String(), test.name)
+	}
+
+	// Free position beyond the buffer, string length beyond the buffer
+	var d Encoded
+	d[cPosArgsCount], d[cPosArgsFree] = 1, 200
+	d[cPosArgsContent], d[cPosArgsContent+1] = cArgTypeString, 255
+	nodes := d.Dump()
+	require.NotEmpty(t, nodes[3].Error)
+	require.NotEmpty(t, nodes[4].Error)
+
+	// Free position beyond the buffer, expiry is set
+	d = Encoded{}
+	d[cPosArgsFree] = 200
+	d[cPosArgsContent], d[cPosArgsContent+1] = cArgTypeExpiry, 1
+	nodes = d.Dump()
+	require.NotEmpty(t, nodes[3].Error)
+	require.Equal(t, "Expiry", nodes[4].Type)
+	require.Empty(t, nodes[4].Error)
+	require.NotPanics(t, func() { _ = d.String() })
+
+	// Truncated expiry up to the end of the buffer
+	for i := int(cPosArgsContent) + 1; i < len(d); i++ {
+		d[i] = 0x80
+	}
+	nodes = d.Dump()
+	require.Equal(t, "truncated expiry", nodes[4].Error)
+}
+
+func TestDumpJSON(t *testing.T) {
+
+	d := New()
+	d.PutArgFloat64(math.NaN())
+	d.PutArgUint8(5)
+
+	b, err := d.DumpJSON()
+	require.NoError(t, err)
+
+	var nodes []map[string]interface{}
+	require.NoError(t, json.Unmarshal(b, &nodes))
+	require.Len(t, nodes, 6)
+	require.Equal(t, "NaN", nodes[4]["value"])
+	require.Equal(t, float64(5), nodes[5]["value"])
+	require.Equal(t, "Argument (uint8)", nodes[5]["type"])
+}
@@ -5,14 +5,21 @@
 
 package ikba
 
-// EncodedDumpNode is type for method Encoded.dump.
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+)
+
+// EncodedDumpNode is type for method Encoded.Dump.
 //
 // This type represents one node of encoded IKB action Encoded.
-// All fields has JSON tags and it's easy to JSON dump output.
+// All fields has JSON tags and it's easy to JSON dump output
+// (see Encoded.DumpJSON).
 //
-// Object of this type fills by Encoded.dump method.
+// Object of this type fills by Encoded.Dump method.
 //
-// More info: Encoded, Encoded.dump.
+// More info: Encoded, Encoded.Dump.
 type EncodedDumpNode struct {
 
 	// Type is a description of IKB encoded node.
@@ -41,4 +48,37 @@ type EncodedDumpNode struct {
 	// Thus, for example, if node about encoded int8 argument, Value is this
 	// int8 argument.
 	Value interface{} `json:"value"`
+
+	// Name of named argument if node is about it.
+	// Otherwise it is empty.
+	Key string `json:"key,omitempty"`
+
+	// Description of the problem if node is malformed
+	// (unknown type header, truncated argument, etc).
+	// Otherwise it is empty.
+	Error string `json:"error,omitempty"`
+}
+
+// MarshalJSON returns JSON representation of node n.
+// Unlike default JSON encoding, it never fails: non-finite float values
+// (NaN, Inf) are encoded as strings.
+func (n EncodedDumpNode) MarshalJSON() ([]byte, error) {
+
+	// Alias type has no MarshalJSON method, so there's no recursion
+	type node EncodedDumpNode
+
+	switch v := n.Value.(type) {
+
+	case float32:
+		if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+			n.Value = strconv.FormatFloat(float64(v), 'g', -1, 32)
+		}
+
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			n.Value = strconv.FormatFloat(v, 'g', -1, 64)
+		}
+	}
+
+	return json.Marshal(node(n))
 }
@@ -0,0 +1,282 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package ikba
+
+// ArgType is a type of encoded argument.
+// Use Encoded.ArgType to get a type of argument with some index.
+type ArgType byte
+
+// Constants of ArgType.
+// They are the same as the internal argument's type headers.
+const (
+
+	// There is no argument (index is out of range).
+	ArgTypeNone ArgType = 0
+
+	ArgTypeInt8    = ArgType(cArgTypeInt8)
+	ArgTypeInt16   = ArgType(cArgTypeInt16)
+	ArgTypeInt32   = ArgType(cArgTypeInt32)
+	ArgTypeInt64   = ArgType(cArgTypeInt64)
+	ArgTypeUint8   = ArgType(cArgTypeUint8)
+	ArgTypeUint16  = ArgType(cArgTypeUint16)
+	ArgTypeUint32  = ArgType(cArgTypeUint32)
+	ArgTypeUint64  = ArgType(cArgTypeUint64)
+	ArgTypeFloat32 = ArgType(cArgTypeFloat32)
+	ArgTypeFloat64 = ArgType(cArgTypeFloat64)
+	ArgTypeString  = ArgType(cArgTypeString)
+	ArgTypeVarint  = ArgType(cArgTypeVarint)
+	ArgTypeUvarint = ArgType(cArgTypeUvarint)
+	ArgTypeBool    = ArgType(cArgTypeFalse)
+	ArgTypeBools   = ArgType(cArgTypeBools)
+	ArgTypeBytes   = ArgType(cArgTypeBytes)
+)
+
+// String returns a string name of argument's type t.
+func (t ArgType) String() string {
+
+	if t == ArgTypeNone {
+		return "none"
+	}
+	return (*Encoded)(nil).argType2S(byte(t))
+}
+
+// ArgType returns the type of argument with exactly argIdx index
+// (negative index counts from the end).
+// If there is no such argument, ArgTypeNone is returned.
+//
+// Named arguments have the type of their value.
+func (d *Encoded) ArgType(argIdx int) ArgType {
+
+	pos := d.argPos(argIdx)
+	if pos == cPosErr {
+		return ArgTypeNone
+	}
+
+	// Both bool headers (false and true) are the same type,
+	// stored arguments have the type of original argument
+	return ArgType(argTypeNormalize(d[d.argSkipKey(pos)]))
+}
+
+// argGetAt returns a position where content of argument with exactly
+// argIdx index starts from if this argument has type argType
+// (or any of argTypes).
+// Unlike argGet, it never searches further.
+//
+// If index is out of range or argument has another type, cPosErr is returned.
+func (d *Encoded) argGetAt(argIdx int, argTypes ...byte) (startPos byte) {
+
+	startPos = d.argPos(argIdx)
+	if startPos == cPosErr {
+		return cPosErr
+	}
+
+	if startPos = d.argSkipKey(startPos); !argTypeIs(d[startPos], argTypes...) {
+		return cPosErr
+	}
+	return startPos + 1
+}
+
+// GetArgAt extracts argument with exactly argIdx index from encoded
+// IKB action d (negative index counts from the end).
+// The type of v depends on argument's type (see ArgType).
+//
+// Returns it and true as success if it is, or nil and false if error.
+func (d *Encoded) GetArgAt(argIdx int) (v interface{}, success bool) {
+
+	pos := d.argPos(argIdx)
+	if pos == cPosErr {
+		return nil, false
+	}
+	return d.argValue(d.argSkipKey(pos))
+}
+
+// GetArgIntAt extracts int argument with exactly argIdx index
+// from encoded IKB action d (negative index counts from the end).
+// Like GetArgInt, it extracts signed varint or int32 argument.
+//
+// Returns it and true as success if it is, or zero value and false if error
+// (argument is absent or it has another type, use ArgType to know which one).
+func (d *Encoded) GetArgIntAt(argIdx int) (v int, success bool) {
+	return d.argInt(d.argGetAt(argIdx, cArgTypeVarint, cArgTypeInt32))
+}
+
+// GetArgInt8At extracts int8 argument with exactly argIdx index
+// from encoded IKB action d (negative index counts from the end).
+//
+// Returns it and true as success if it is, or zero value and false if error
+// (argument is absent or it has another type, use ArgType to know which one).
+func (d *Encoded) GetArgInt8At(argIdx int) (v int8, success bool) {
+
+	startPos := d.argGetAt(argIdx, cArgTypeInt8)
+	if startPos == cPosErr {
+		return 0, false
+	}
+	vv, _ := d.argValue(startPos - 1)
+	return vv.(int8), true
+}
+
+// GetArgInt16At extracts int16 argument with exactly argIdx index
+// from encoded IKB action d (negative index counts from the end).
+//
+// Returns it and true as success if it is, or zero value and false if error
+// (argument is absent or it has another type, use ArgType to know which one).
+func (d *Encoded) GetArgInt16At(argIdx int) (v int16, success bool) {
+
+	startPos := d.argGetAt(argIdx, cArgTypeInt16)
+	if startPos == cPosErr {
+		return 0, false
+	}
+	vv, _ := d.argValue(startPos - 1)
+	return vv.(int16), true
+}
+
+// GetArgInt32At extracts int32 argument with exactly argIdx index
+// from encoded IKB action d (negative index counts from the end).
+//
+// Returns it and true as success if it is, or zero value and false if error
+// (argument is absent or it has another type, use ArgType to know which one).
+func (d *Encoded) GetArgInt32At(argIdx int) (v int32, success bool) {
+
+	startPos := d.argGetAt(argIdx, cArgTypeInt32)
+	if startPos == cPosErr {
+		return 0, false
+	}
+	vv, _ := d.argValue(startPos - 1)
+	return vv.(int32), true
+}
+
+// GetArgInt64At extracts int64 argument with exactly argIdx index
+// from encoded IKB action d (negative index counts from the end).
+//
+// Returns it and true as success if it is, or zero value and false if error
+// (argument is absent or it has another type, use ArgType to know which one).
+func (d *Encoded) GetArgInt64At(argIdx int) (v int64, success bool) {
+
+	startPos := d.argGetAt(argIdx, cArgTypeInt64)
+	if startPos == cPosErr {
+		return 0, false
+	}
+	vv, _ := d.argValue(startPos - 1)
+	return vv.(int64), true
+}
+
+// GetArgUintAt extracts uint argument with exactly argIdx index
+// from encoded IKB action d (negative index counts from the end).
+// Like GetArgUint, it extracts unsigned varint or uint32 argument.
+//
+// Returns it and true as success if it is, or zero value and false if error
+// (argument is absent or it has another type, use ArgType to know which one).
+func (d *Encoded) GetArgUintAt(argIdx int) (v uint, success bool) {
+	return d.argUint(d.argGetAt(argIdx, cArgTypeUvarint, cArgTypeUint32))
+}
+
+// GetArgUint8At extracts uint8 argument with exactly argIdx index
+// from encoded IKB action d (negative index counts from the end).
+//
+// Returns it and true as success if it is, or zero value and false if error
+// (argument is absent or it has another type, use ArgType to know which one).
+func (d *Encoded) GetArgUint8At(argIdx int) (v uint8, success bool) {
+
+	startPos := d.argGetAt(argIdx, cArgTypeUint8)
+	if startPos == cPosErr {
+		return 0, false
+	}
+	vv, _ := d.argValue(startPos - 1)
+	return vv.(uint8), true
+}
+
+// GetArgUint16At extracts uint16 argument with exactly argIdx index
+// from encoded IKB action d (negative index counts from the end).
+//
+// Returns it and true as success if it is, or zero value and false if error
+// (argument is absent or it has another type, use ArgType to know which one).
+func (d *Encoded) GetArgUint16At(argIdx int) (v uint16, success bool) {
+
+	startPos := d.argGetAt(argIdx, cArgTypeUint16)
+	if startPos == cPosErr {
+		return 0, false
+	}
+	vv, _ := d.argValue(startPos - 1)
+	return vv.(uint16), true
+}
+
+// GetArgUint32At extracts uint32 argument with exactly argIdx index
+// from encoded IKB action d (negative index counts from the end).
+//
+// Returns it and true as success if it is, or zero value and false if error
+// (argument is absent or it has another type, use ArgType to know which one).
+func (d *Encoded) GetArgUint32At(argIdx int) (v uint32, success bool) {
+
+	startPos := d.argGetAt(argIdx, cArgTypeUint32)
+	if startPos == cPosErr {
+		return 0, false
+	}
+	vv, _ := d.argValue(startPos - 1)
+	return vv.(uint32), true
+}
+
+// GetArgUint64At extracts uint64 argument with exactly argIdx index
+// from encoded IKB action d (negative index counts from the end).
+//
+// Returns it and true as success if it is, or zero value and false if error
+// (argument is absent or it has another type, use ArgType to know which one).
+func (d *Encoded) GetArgUint64At(argIdx int) (v uint64, success bool) {
+
+	startPos := d.argGetAt(argIdx, cArgTypeUint64)
+	if startPos == cPosErr {
+		return 0, false
+	}
+	vv, _ := d.argValue(startPos - 1)
+	return vv.(uint64), true
+}
+
+// GetArgFloat32At extracts float32 argument with exactly argIdx index
+// from encoded IKB action d (negative index counts from the end).
+//
+// Returns it and true as success if it is, or zero value and false if error
+// (argument is absent or it has another type, use ArgType to know which one).
+func (d *Encoded) GetArgFloat32At(argIdx int) (v float32, success bool) {
+
+	startPos := d.argGetAt(argIdx, cArgTypeFloat32)
+	if startPos == cPosErr {
+		return 0, false
+	}
+	vv, _ := d.argValue(startPos - 1)
+	return vv.(float32), true
+}
+
+// GetArgFloat64At extracts float64 argument with exactly argIdx index
+// from encoded IKB action d (negative index counts from the end).
+//
+// Returns it and true as success if it is, or zero value and false if error
+// (argument is absent or it has another type, use ArgType to know which one).
+func (d *Encoded) GetArgFloat64At(argIdx int) (v float64, success bool) {
+
+	startPos := d.argGetAt(argIdx, cArgTypeFloat64)
+	if startPos == cPosErr {
+		return 0, false
+	}
+	vv, _ := d.argValue(startPos - 1)
+	return vv.(float64), true
+}
+
+// GetArgStringAt extracts string argument with exactly argIdx index
+// from encoded IKB action d (negative index counts from the end).
+//
+// Returns it and true as success if it is, or zero value and false if error
+// (argument is absent or it has another type, use ArgType to know which one).
+func (d *Encoded) GetArgStringAt(argIdx int) (v string, success bool) {
+
+	startPos := d.argGetAt(argIdx, cArgTypeString)
+	if startPos == cPosErr {
+		return "", false
+	}
+	vv, success := d.argValue(startPos - 1)
+	if !success {
+		return "", false
+	}
+	return vv.(string), true
+}
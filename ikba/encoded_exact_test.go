@@ -0,0 +1,54 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package ikba
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetArgAt(t *testing.T) {
+
+	d := New()
+	d.PutArgInt32(1)
+	d.PutArgInt16(2)
+	d.PutArgString("s")
+	d.PutArgInt32(3)
+
+	// Search getter finds argument 3, exact getter fails
+	v, ok := d.GetArgInt32(1)
+	require.True(t, ok)
+	require.Equal(t, int32(3), v)
+
+	_, ok = d.GetArgInt32At(1)
+	require.False(t, ok)
+
+	v, ok = d.GetArgInt32At(-1)
+	require.True(t, ok)
+	require.Equal(t, int32(3), v)
+
+	i, ok := d.GetArgIntAt(0)
+	require.True(t, ok)
+	require.Equal(t, 1, i)
+
+	s, ok := d.GetArgStringAt(2)
+	require.True(t, ok)
+	require.Equal(t, "s", s)
+
+	vv, ok := d.GetArgAt(1)
+	require.True(t, ok)
+	require.Equal(t, int16(2), vv)
+
+	_, ok = d.GetArgAt(4)
+	require.False(t, ok)
+
+	require.Equal(t, ArgTypeInt16, d.ArgType(1))
+	require.Equal(t, ArgTypeString, d.ArgType(-2))
+	require.Equal(t, ArgTypeNone, d.ArgType(4))
+	require.Equal(t, "int16", d.ArgType(1).String())
+	require.Equal(t, "none", ArgTypeNone.String())
+}
@@ -0,0 +1,153 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package ikba
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+// Expiry.
+//
+// Encoded IKB action may have the deadline after which it stops working
+// (one-time confirmations, time-limited offers, etc). It's stored as
+// minutes since Epoch (uvarint) right before the first argument
+// and requires 4-5 bytes for the deadlines of the next decades.
+// It's not an argument: it has no index and doesn't change arguments'
+// indexes.
+//
+// Decode returns ErrActionExpired for encoded IKB action which deadline
+// has passed.
+
+// ErrActionExpired means that the deadline of encoded IKB action
+// (see SetExpiry) has passed.
+var ErrActionExpired = errors.New("ikba: encoded IKB action has expired")
+
+// Epoch is the time expiry of encoded IKB action is counted from.
+// Change it only at startup (if ever): the deadlines of already sent
+// encoded IKB actions will be shifted.
+var Epoch = time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// argsStart returns the position where the first argument starts from
+// (after expiry if it's set).
+//
+// If expiry is malformed, cPosErr is returned.
+func (d *Encoded) argsStart() (pos byte) {
+
+	if d[cPosArgsContent] != cArgTypeExpiry || d[cPosArgsFree] <= cPosArgsContent {
+		return cPosArgsContent
+	}
+
+	nextPos, err := d.expiryEnd(int(d[cPosArgsFree]))
+	if err != nil {
+		return cPosErr
+	}
+	return byte(nextPos)
+}
+
+// expiryEnd returns the position right after expiry if expiry is set
+// (and it ends not beyond endPos), or the position of the first argument
+// if it's not. EndPos greater than len of d (malformed next free position)
+// is len of d.
+//
+// If expiry is truncated, ErrBadArgLength is returned.
+func (d *Encoded) expiryEnd(endPos int) (nextPos int, err error) {
+
+	if endPos > len(d) {
+		endPos = len(d)
+	}
+
+	pos := int(cPosArgsContent)
+	if pos >= endPos || d[pos] != cArgTypeExpiry {
+		return pos, nil
+	}
+
+	// Truncated (n == 0) or overflowed (n < 0) minutes
+	_, n := binary.Uvarint(d[pos+1 : endPos])
+	if n <= 0 {
+		return 0, ErrBadArgLength
+	}
+	return pos + 1 + n, nil
+}
+
+// Expiry returns the deadline of encoded IKB action d
+// and true if it's set (see SetExpiry), otherwise false.
+func (d *Encoded) Expiry() (deadline time.Time, success bool) {
+
+	startPos := d.argsStart()
+	if startPos == cPosErr || startPos == cPosArgsContent {
+		return time.Time{}, false
+	}
+
+	minutes, _ := binary.Uvarint(d[cPosArgsContent+1 : startPos])
+	return Epoch.Add(time.Duration(minutes) * time.Minute), true
+}
+
+// Expired reports whether the deadline of encoded IKB action d
+// is set and it has passed.
+func (d *Encoded) Expired() bool {
+
+	deadline, success := d.Expiry()
+	return success && !time.Now().Before(deadline)
+}
+
+// SetExpiry sets the deadline of encoded IKB action d.
+// Deadline is rounded up to the minute (thus encoded IKB action
+// never expires earlier) and deadlines before Epoch are Epoch.
+//
+// Expiry is placed before arguments, they are moved and their indexes
+// are not changed. Returns true if deadline has been set,
+// or false if there is no space for it.
+func (d *Encoded) SetExpiry(deadline time.Time) (success bool) {
+
+	var minutes uint64
+	if since := deadline.Sub(Epoch); since > 0 {
+		minutes = uint64((since + time.Minute - 1) / time.Minute)
+	}
+
+	var buf [1 + binary.MaxVarintLen64]byte
+	buf[0] = cArgTypeExpiry
+	return d.expiryReplace(buf[:1+binary.PutUvarint(buf[1:], minutes)])
+}
+
+// ClearExpiry removes the deadline of encoded IKB action d (if any).
+func (d *Encoded) ClearExpiry() {
+	d.expiryReplace(nil)
+}
+
+// expiryReplace replaces current expiry of encoded IKB action d
+// (if any) by encoded expiry entry (or removes it if entry is empty),
+// moving arguments. Returns false if there is no space for entry
+// or d is malformed.
+func (d *Encoded) expiryReplace(entry []byte) (success bool) {
+
+	startPos := d.argsStart()
+	if startPos == cPosErr {
+		return false
+	}
+
+	var (
+		freePos    = int(d[cPosArgsFree])
+		newArgsPos = int(cPosArgsContent) + len(entry)
+		newFreePos = freePos - int(startPos) + newArgsPos
+	)
+
+	if newFreePos > int(posFreeMax()) {
+		return false
+	}
+
+	copy(d[newArgsPos:], d[startPos:freePos])
+	copy(d[cPosArgsContent:], entry)
+
+	// Zero the freed tail, if expiry became shorter
+	for i := newFreePos; i < freePos; i++ {
+		d[i] = 0
+	}
+
+	d[cPosArgsFree] = byte(newFreePos)
+	return true
+}
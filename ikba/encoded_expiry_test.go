@@ -0,0 +1,109 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package ikba
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpiry(t *testing.T) {
+
+	d := New()
+	d.PutArgInt32(100)
+	d.PutArgString("foo")
+
+	_, ok := d.Expiry()
+	require.False(t, ok)
+	require.False(t, d.Expired())
+
+	// Deadline is rounded up to the minute
+	deadline := Epoch.Add(1000*time.Hour + 30*time.Second)
+	require.True(t, d.SetExpiry(deadline))
+
+	got, ok := d.Expiry()
+	require.True(t, ok)
+	require.Equal(t, Epoch.Add(1000*time.Hour+time.Minute), got)
+	require.True(t, d.Expired())
+
+	// Arguments are kept, expiry is not counted
+	require.Equal(t, 2, d.ArgCount())
+	v, ok := d.GetArgInt32(0)
+	require.True(t, ok)
+	require.Equal(t, int32(100), v)
+	s, ok := d.GetArgStringAt(1)
+	require.True(t, ok)
+	require.Equal(t, "foo", s)
+
+	// Replacing by longer and shorter expiry
+	free := d.Free()
+	require.True(t, d.SetExpiry(Epoch.Add(100000*time.Hour)))
+	require.Equal(t, free-1, d.Free())
+	require.True(t, d.SetExpiry(Epoch))
+	require.Equal(t, free+2, d.Free())
+
+	d.ClearExpiry()
+	_, ok = d.Expiry()
+	require.False(t, ok)
+
+	s, ok = d.GetArgStringAt(1)
+	require.True(t, ok)
+	require.Equal(t, "foo", s)
+	require.Equal(t, New().Free()-10, d.Free())
+}
+
+func TestExpiryNoSpace(t *testing.T) {
+
+	d := New()
+	require.NotEqual(t, cBadIndex, d.PutArgString(strings.Repeat("x", d.Free()-2)))
+
+	require.False(t, d.SetExpiry(time.Now()))
+	_, ok := d.Expiry()
+	require.False(t, ok)
+}
+
+func TestDecodeExpiry(t *testing.T) {
+
+	d := New()
+	d.PutArgInt32(100)
+	require.True(t, d.SetExpiry(time.Now().Add(time.Hour)))
+
+	decoded, err := Decode(d.CallbackData())
+	require.NoError(t, err)
+	require.Equal(t, d, decoded)
+
+	require.True(t, d.SetExpiry(time.Now().Add(-time.Minute)))
+
+	_, err = Decode(d.CallbackData())
+	require.Equal(t, ErrActionExpired, err)
+
+	header := []byte{1, 0, 0, 0, 0, 0, 0, 0}
+
+	// Truncated expiry is malformed, not expired
+	_, err = Decode(encodeRaw(append(header, 0, 12, cArgTypeExpiry, 0x80)...))
+	require.Equal(t, ErrBadArgLength, err)
+
+	// Expiry is allowed only before the first argument
+	_, err = Decode(encodeRaw(append(header, 1, 14, cArgTypeInt8, 1, cArgTypeExpiry, 1)...))
+	require.Equal(t, ErrBadArgType, err)
+}
+
+func TestDumpExpiry(t *testing.T) {
+
+	d := New()
+	d.PutArgInt32(100)
+	d.SetExpiry(time.Date(2019, time.June, 1, 12, 0, 0, 0, time.UTC))
+
+	nodes := d.Dump()
+	require.Len(t, nodes, 6)
+	require.Equal(t, "Expiry", nodes[4].Type)
+	require.Empty(t, nodes[2].Error)
+
+	require.Equal(t, "{view:0 ssid:0 expires:2019-06-01T12:00:00Z args:[int32(100)]}", d.String())
+}
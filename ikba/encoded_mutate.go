@@ -0,0 +1,223 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package ikba
+
+// Range calls f for each argument of encoded IKB action d in order
+// with argument's index, type (see ArgType) and value (see GetArgAt).
+// If f returns false, Range stops the iteration.
+//
+// Value of expired stored argument (see SetStore) is nil.
+func (d *Encoded) Range(f func(argIdx int, argType ArgType, v interface{}) bool) {
+
+	pos := d.argsStart()
+	for i, n := 0, d.ArgCount(); i < n && pos != cPosErr; i++ {
+
+		headerPos := d.argSkipKey(pos)
+		v, _ := d.argValue(headerPos)
+
+		if !f(i, ArgType(argTypeNormalize(d[headerPos])), v) {
+			return
+		}
+		pos = d.argNextFromPos(pos)
+	}
+}
+
+// argBounds returns the position where argument with index argIdx starts
+// from (its key prefix for named argument) and the position of its type
+// header and the position of the next argument.
+//
+// If index is out of range or d is malformed, ok is false.
+func (d *Encoded) argBounds(argIdx int) (pos, headerPos, nextPos int, ok bool) {
+
+	argPos := d.argPos(argIdx)
+	if argPos == cPosErr {
+		return 0, 0, 0, false
+	}
+	pos, headerPos = int(argPos), int(d.argSkipKey(argPos))
+
+	nextPos, err := d.argEnd(headerPos, int(d[cPosArgsFree]))
+	return pos, headerPos, nextPos, err == nil
+}
+
+// argPutTyped puts argument v to the encoded IKB action d as argument
+// with type header argType (see argTypeNormalize). The type of v must be
+// the type argument of that type is extracted as (see GetArgAt),
+// but int is allowed for varint and uint for uvarint.
+//
+// If it was successfully, returns the index of that argument.
+// Otherwise -1 is returned (argument has not been added).
+func (d *Encoded) argPutTyped(argType byte, v interface{}) (argIdx int) {
+
+	switch v := v.(type) {
+
+	case int8:
+		if argType == cArgTypeInt8 {
+			return d.PutArgInt8(v)
+		}
+	case int16:
+		if argType == cArgTypeInt16 {
+			return d.PutArgInt16(v)
+		}
+	case int32:
+		if argType == cArgTypeInt32 {
+			return d.PutArgInt32(v)
+		}
+	case int64:
+		switch argType {
+		case cArgTypeInt64:
+			return d.PutArgInt64(v)
+		case cArgTypeVarint:
+			return d.PutArgVarint(v)
+		}
+	case int:
+		if argType == cArgTypeVarint {
+			return d.PutArgInt(v)
+		}
+	case uint8:
+		if argType == cArgTypeUint8 {
+			return d.PutArgUint8(v)
+		}
+	case uint16:
+		if argType == cArgTypeUint16 {
+			return d.PutArgUint16(v)
+		}
+	case uint32:
+		if argType == cArgTypeUint32 {
+			return d.PutArgUint32(v)
+		}
+	case uint64:
+		switch argType {
+		case cArgTypeUint64:
+			return d.PutArgUint64(v)
+		case cArgTypeUvarint:
+			return d.PutArgUvarint(v)
+		}
+	case uint:
+		if argType == cArgTypeUvarint {
+			return d.PutArgUint(v)
+		}
+	case float32:
+		if argType == cArgTypeFloat32 {
+			return d.PutArgFloat32(v)
+		}
+	case float64:
+		if argType == cArgTypeFloat64 {
+			return d.PutArgFloat64(v)
+		}
+	case string:
+		switch argType {
+		case cArgTypeString:
+			return d.PutArgString(v)
+		case cArgTypeDigits, cArgTypeHex, cArgTypeAlnum:
+			return d.argPutPacked(argType, v)
+		}
+	case bool:
+		if argType == cArgTypeFalse {
+			return d.PutArgBool(v)
+		}
+	case []bool:
+		if argType == cArgTypeBools {
+			return d.PutArgBools(v...)
+		}
+	case []byte:
+		if argType == cArgTypeBytes {
+			return d.PutArgBytes(v)
+		}
+	}
+
+	return cBadIndex
+}
+
+// SetArg replaces the value of argument with exactly argIdx index
+// (negative index counts from the end) of encoded IKB action d by v.
+// The index, the type and the key (for named argument) are kept.
+//
+// The type of v must be the type of argument's value (see GetArgAt),
+// but int is allowed for varint and uint for uvarint.
+// The new value must have the same width as the old one
+// (the same length for strings, bytes and bools, the same number of bytes
+// for varints). Stored argument (see SetStore) may have any new value,
+// packed string (see PutArgStringPacked) must stay in the same alphabet.
+//
+// The new value of stored argument is kept with the new key,
+// the old value is not changed (it may be used by clones or already sent
+// buttons).
+//
+// Returns true if argument has been replaced, otherwise false.
+func (d *Encoded) SetArg(argIdx int, v interface{}) (success bool) {
+
+	_, headerPos, nextPos, ok := d.argBounds(argIdx)
+	if !ok {
+		return false
+	}
+
+	// Stored argument: the new value is kept with the new key, because
+	// the old key may be shared with clones or already sent buttons
+	switch d[headerPos] {
+
+	case cArgTypeStoredString:
+		if vv, ok := v.(string); ok {
+			return d.argSetStored(byte(headerPos), []byte(vv))
+		}
+		return false
+
+	case cArgTypeStoredBytes:
+		if vv, ok := v.([]byte); ok {
+			return d.argSetStored(byte(headerPos), vv)
+		}
+		return false
+	}
+
+	// Encode the new value apart and replace the old one
+	// if they have the same width
+	var tmp Encoded
+	tmp.init()
+
+	// Packed string is replaced by packed string of the same alphabet
+	argType := argTypeNormalize(d[headerPos])
+	if argType == cArgTypeString {
+		argType = d[headerPos]
+	}
+
+	if tmp.argPutTyped(argType, v) == cBadIndex ||
+		int(tmp[cPosArgsFree]-cPosArgsContent) != nextPos-headerPos {
+		return false
+	}
+
+	copy(d[headerPos:nextPos], tmp[cPosArgsContent:tmp[cPosArgsFree]])
+	return true
+}
+
+// RemoveArg removes argument with exactly argIdx index (negative index
+// counts from the end) from encoded IKB action d. Next arguments are
+// moved to its place, thus their indexes are decreased by 1,
+// and the freed bytes can be used by new arguments.
+//
+// The value of removed stored argument (see SetStore) is kept
+// in the store until it expires: it may be shared with clones
+// or already sent buttons (see FileStore.Purge to remove expired values).
+//
+// Returns true if argument has been removed, otherwise false.
+func (d *Encoded) RemoveArg(argIdx int) (success bool) {
+
+	pos, _, nextPos, ok := d.argBounds(argIdx)
+	if !ok {
+		return false
+	}
+
+	freePos := int(d[cPosArgsFree])
+	copy(d[pos:], d[nextPos:freePos])
+
+	// Zero the freed tail, so removed argument is not leaked
+	newFreePos := freePos - (nextPos - pos)
+	for i := newFreePos; i < freePos; i++ {
+		d[i] = 0
+	}
+
+	d[cPosArgsFree] = byte(newFreePos)
+	d[cPosArgsCount]--
+	return true
+}
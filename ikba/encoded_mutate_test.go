@@ -0,0 +1,131 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package ikba
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRange(t *testing.T) {
+
+	d := New()
+	d.PutArgInt(2)
+	d.PutArgString("foo")
+	d.PutArgBool(true)
+
+	var (
+		types  []ArgType
+		values []interface{}
+	)
+	d.Range(func(argIdx int, argType ArgType, v interface{}) bool {
+		require.Equal(t, len(types), argIdx)
+		types = append(types, argType)
+		values = append(values, v)
+		return argIdx < 1
+	})
+
+	require.Equal(t, []ArgType{ArgTypeVarint, ArgTypeString}, types)
+	require.Equal(t, []interface{}{int64(2), "foo"}, values)
+}
+
+func TestSetArg(t *testing.T) {
+
+	d := New()
+	d.PutArgInt(2)
+	d.PutArgUint16(10)
+	d.PutArgString("foo")
+	d.PutArgBool(false)
+
+	require.True(t, d.SetArg(0, 3))
+	require.True(t, d.SetArg(1, uint16(11)))
+	require.True(t, d.SetArg(-2, "bar"))
+	require.True(t, d.SetArg(-1, true))
+
+	// Another width, another type, out of range
+	require.False(t, d.SetArg(0, 1000))
+	require.False(t, d.SetArg(2, "longer"))
+	require.False(t, d.SetArg(1, 11))
+	require.False(t, d.SetArg(4, 1))
+
+	v, _ := d.GetArgIntAt(0)
+	require.Equal(t, 3, v)
+	u, _ := d.GetArgUint16At(1)
+	require.Equal(t, uint16(11), u)
+	s, _ := d.GetArgStringAt(2)
+	require.Equal(t, "bar", s)
+	b, _ := d.GetArgBoolAt(3)
+	require.True(t, b)
+
+	require.NoError(t, d.validate())
+}
+
+func TestSetArgStored(t *testing.T) {
+
+	SetStore(NewMemoryStore(10), time.Minute)
+	defer SetStore(nil, 0)
+
+	d := New()
+	d.PutArgString(strings.Repeat("a", 60))
+
+	original := d.Clone()
+
+	require.True(t, d.SetArg(0, "b"))
+	v, _ := d.GetArgStringAt(0)
+	require.Equal(t, "b", v)
+
+	// Clone (and already sent button) keeps the old value
+	v, _ = original.GetArgStringAt(0)
+	require.Equal(t, strings.Repeat("a", 60), v)
+}
+
+func TestRemoveArg(t *testing.T) {
+
+	require.NoError(t, RegisterKeys(-300, "page"))
+
+	d := New()
+	d.PutViewID(-300)
+	d.PutArgInt8(1)
+	d.PutArgNamed("page", 5)
+	d.PutArgString("foo")
+	free := d.Free()
+
+	require.True(t, d.RemoveArg(1))
+	require.Equal(t, 2, d.ArgCount())
+	require.Equal(t, free+4, d.Free())
+	require.Equal(t, cBadIndex, d.ArgIndex("page"))
+
+	s, ok := d.GetArgStringAt(1)
+	require.True(t, ok)
+	require.Equal(t, "foo", s)
+
+	require.True(t, d.RemoveArg(-1))
+	require.False(t, d.RemoveArg(1))
+	require.NoError(t, d.validate())
+
+	expected := New()
+	expected.PutViewID(-300)
+	expected.PutArgInt8(1)
+	require.Equal(t, expected, d)
+}
+
+func TestCloneReset(t *testing.T) {
+
+	d := New()
+	d.PutViewID(1)
+	d.PutArgInt8(1)
+
+	c := d.Clone()
+	require.Equal(t, d, c)
+	c.PutArgInt8(2)
+	require.Equal(t, 1, d.ArgCount())
+
+	c.Reset()
+	require.Equal(t, New(), c)
+}
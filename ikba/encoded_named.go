@@ -0,0 +1,221 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package ikba
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+
+	"github.com/qioalice/devola/core/view"
+)
+
+// Named arguments.
+//
+// Named argument is a positional argument with a key prefix:
+// < Key header : 1 byte > < Key ID : 1 byte > < Argument ... >
+//
+// Key ID is the index (starting from 1) of argument's name in the
+// key table of view the encoded IKB action belongs to.
+// Thus the name itself is not encoded and each named argument
+// requires only 2 bytes more than positional one.
+//
+// Register key table of each view which encoded IKB actions have
+// named arguments using RegisterKeys at startup.
+//
+// Because named argument is a positional argument too, it has index
+// and can be extracted by any GetArg* method.
+
+// Errors that can be returned by RegisterKeys.
+var (
+
+	// ErrKeysRegistered means that key table of view is already registered.
+	ErrKeysRegistered = errors.New("ikba: keys of view are already registered")
+
+	// ErrKeysTooMany means that key table can't have so many names
+	// (key ID is 1 byte).
+	ErrKeysTooMany = errors.New("ikba: too many keys")
+
+	// ErrKeysDuplicate means that key table has the same name twice.
+	ErrKeysDuplicate = errors.New("ikba: duplicate key")
+)
+
+// keyTables is a set of registered key tables of views.
+var keyTables = struct {
+	sync.RWMutex
+	tables map[view.IDEnc][]string
+}{
+	tables: make(map[view.IDEnc][]string),
+}
+
+// RegisterKeys registers the key table (names of named arguments)
+// of view with encoded view ID id.
+//
+// Order of names matters: it defines names' key IDs.
+// Thus append new names only to the end of table to keep
+// already sent encoded IKB actions valid.
+func RegisterKeys(id view.IDEnc, names ...string) error {
+
+	if len(names) > int(^byte(0)) {
+		return ErrKeysTooMany
+	}
+
+	for i := range names {
+		for j := 0; j < i; j++ {
+			if names[i] == names[j] {
+				return ErrKeysDuplicate
+			}
+		}
+	}
+
+	keyTables.Lock()
+	defer keyTables.Unlock()
+
+	if _, found := keyTables.tables[id]; found {
+		return ErrKeysRegistered
+	}
+
+	keyTables.tables[id] = append([]string(nil), names...)
+	return nil
+}
+
+// keyID returns the key ID of name in the key table of view of d.
+// If view has no key table or there is no name in it, 0 is returned.
+func (d *Encoded) keyID(name string) (keyID byte) {
+
+	keyTables.RLock()
+	defer keyTables.RUnlock()
+
+	for i, n := range keyTables.tables[d.GetViewID()] {
+		if n == name {
+			return byte(i + 1)
+		}
+	}
+	return 0
+}
+
+// keyName returns the name of keyID in the key table of view of d.
+// If there is no such key ID, its decimal view is returned.
+func (d *Encoded) keyName(keyID byte) (name string) {
+
+	keyTables.RLock()
+	defer keyTables.RUnlock()
+
+	table := keyTables.tables[d.GetViewID()]
+	if keyID == 0 || int(keyID) > len(table) {
+		return strconv.Itoa(int(keyID))
+	}
+	return table[keyID-1]
+}
+
+// ArgIndex returns the index of named argument name in encoded IKB action d.
+// If there is no such named argument, -1 is returned.
+func (d *Encoded) ArgIndex(name string) (argIdx int) {
+
+	keyID := d.keyID(name)
+	if keyID == 0 {
+		return cBadIndex
+	}
+
+	pos := d.argsStart()
+	for i, n := 0, d.ArgCount(); i < n && pos != cPosErr; i++ {
+		if d[pos] == cArgTypeKey && d[pos+1] == keyID {
+			return i
+		}
+		pos = d.argNextFromPos(pos)
+	}
+
+	return cBadIndex
+}
+
+// PutArgNamed puts named argument name with value v to the encoded
+// IKB action d. Name must be in the key table of d's view (see RegisterKeys),
+// so put view ID before named arguments.
+//
+// Allowed types of v: any integer, float32, float64, string, bool,
+// []bool (bit-packed bools), []byte.
+//
+// If it was successfully, returns the index of that argument.
+// Otherwise -1 is returned (argument has not been added; unknown name,
+// name is already added, not allowed type or no more space).
+func (d *Encoded) PutArgNamed(name string, v interface{}) (argIdx int) {
+
+	keyID := d.keyID(name)
+	if keyID == 0 || d.ArgIndex(name) != cBadIndex {
+		return cBadIndex
+	}
+
+	// Key prefix requires 2 bytes, argument checks the rest itself
+	if !d.argHaveFreeBytes(2) {
+		return cBadIndex
+	}
+
+	// Save key prefix, then argument itself
+	startPos := d[cPosArgsFree]
+	d[startPos+0] = cArgTypeKey
+	d[startPos+1] = keyID
+	d[cPosArgsFree] += 2
+
+	switch v := v.(type) {
+
+	case int:
+		argIdx = d.PutArgInt(v)
+	case int8:
+		argIdx = d.PutArgInt8(v)
+	case int16:
+		argIdx = d.PutArgInt16(v)
+	case int32:
+		argIdx = d.PutArgInt32(v)
+	case int64:
+		argIdx = d.PutArgInt64(v)
+	case uint:
+		argIdx = d.PutArgUint(v)
+	case uint8:
+		argIdx = d.PutArgUint8(v)
+	case uint16:
+		argIdx = d.PutArgUint16(v)
+	case uint32:
+		argIdx = d.PutArgUint32(v)
+	case uint64:
+		argIdx = d.PutArgUint64(v)
+	case float32:
+		argIdx = d.PutArgFloat32(v)
+	case float64:
+		argIdx = d.PutArgFloat64(v)
+	case string:
+		argIdx = d.PutArgString(v)
+	case bool:
+		argIdx = d.PutArgBool(v)
+	case []bool:
+		argIdx = d.PutArgBools(v...)
+	case []byte:
+		argIdx = d.PutArgBytes(v)
+	default:
+		argIdx = cBadIndex
+	}
+
+	// Rollback key prefix if argument has not been added
+	if argIdx == cBadIndex {
+		d[cPosArgsFree] = startPos
+		d[startPos+0], d[startPos+1] = 0, 0
+	}
+
+	return argIdx
+}
+
+// GetArgNamed extracts named argument name from encoded IKB action d.
+// The type of v is the same as the type of value argument has been put with
+// (but int is int64 and uint is uint64, they are varints).
+//
+// Returns it and true as success if it is, or nil and false if error.
+func (d *Encoded) GetArgNamed(name string) (v interface{}, success bool) {
+
+	argIdx := d.ArgIndex(name)
+	if argIdx == cBadIndex {
+		return nil, false
+	}
+	return d.argValue(d.argSkipKey(d.argPos(argIdx)))
+}
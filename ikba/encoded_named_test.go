@@ -0,0 +1,66 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package ikba
+
+import (
+	"testing"
+
+	"github.com/qioalice/devola/core/view"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamedArgs(t *testing.T) {
+
+	const id = view.IDEnc(1012)
+	require.NoError(t, RegisterKeys(id, "page", "query", "nonce"))
+	require.Equal(t, ErrKeysRegistered, RegisterKeys(id, "page"))
+	require.Equal(t, ErrKeysDuplicate, RegisterKeys(id+1, "a", "a"))
+
+	d := New()
+	d.PutViewID(id)
+
+	require.Equal(t, 0, d.PutArgInt8(5))
+	require.Equal(t, 1, d.PutArgNamed("query", "cats"))
+	require.Equal(t, 2, d.PutArgNamed("page", uint16(3)))
+	require.Equal(t, cBadIndex, d.PutArgNamed("page", uint16(4)))
+	require.Equal(t, cBadIndex, d.PutArgNamed("unknown", 1))
+	require.Equal(t, cBadIndex, d.PutArgNamed("nonce", struct{}{}))
+
+	// positional int8 + (key + string) + (key + uint16)
+	require.Equal(t, cPosArgsContent+2+(2+2+4)+(2+3), d[cPosArgsFree])
+
+	v, ok := d.GetArgNamed("page")
+	require.True(t, ok)
+	require.Equal(t, uint16(3), v)
+
+	v, ok = d.GetArgNamed("query")
+	require.True(t, ok)
+	require.Equal(t, "cats", v)
+
+	_, ok = d.GetArgNamed("nonce")
+	require.False(t, ok)
+
+	// Positional readers keep working
+	v8, ok := d.GetArgInt8(0)
+	require.True(t, ok)
+	require.Equal(t, int8(5), v8)
+
+	s, ok := d.GetArgString(1)
+	require.True(t, ok)
+	require.Equal(t, "cats", s)
+
+	u16, ok := d.GetArgUint16(-1)
+	require.True(t, ok)
+	require.Equal(t, uint16(3), u16)
+
+	require.Equal(t, 2, d.ArgIndex("page"))
+	require.Equal(t, cBadIndex, d.ArgIndex("nonce"))
+
+	decoded, err := Decode(d.CallbackData())
+	require.NoError(t, err)
+	require.Equal(t, 1, decoded.ArgIndex("query"))
+}
@@ -0,0 +1,158 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package ikba
+
+import (
+	"strings"
+)
+
+// Packed strings.
+//
+// String argument requires 1 byte for each char. Strings of restricted
+// alphabets can be packed denser (see PutArgStringPacked):
+// decimal digits and lowercase hex digits require 4 bits for each char,
+// lowercase alphanumerics ([0-9a-z]) require 6 bits for each char.
+// Anyway 2 bytes are required for type header and number of chars.
+//
+// Packed string is transparent: it has string type (see ArgType)
+// and it's extracted by GetArgString (GetArgStringAt, etc).
+
+// Alphabets of packed strings. The index of char in the alphabet
+// is its encoded value.
+const (
+	cPackedDigits = "0123456789"
+	cPackedHex    = "0123456789abcdef"
+	cPackedAlnum  = "0123456789abcdefghijklmnopqrstuvwxyz"
+)
+
+// packedAlphabet returns the alphabet and the number of bits per char
+// of packed string with type header argType.
+func packedAlphabet(argType byte) (alphabet string, width uint) {
+
+	switch argType {
+
+	case cArgTypeDigits:
+		return cPackedDigits, 4
+
+	case cArgTypeHex:
+		return cPackedHex, 4
+
+	default:
+		return cPackedAlnum, 6
+	}
+}
+
+// packedLen returns the number of bytes that required to store
+// numChars chars of packed string with type header argType.
+func packedLen(argType, numChars byte) byte {
+
+	_, width := packedAlphabet(argType)
+	return byte((int(numChars)*int(width) + 7) / 8)
+}
+
+// packedMode returns the type header of the densest packed string
+// which alphabet has all chars of v, or 0 if there is no such one.
+func packedMode(v string) (argType byte) {
+
+	for _, argType := range []byte{cArgTypeDigits, cArgTypeHex, cArgTypeAlnum} {
+		alphabet, _ := packedAlphabet(argType)
+		if strings.Trim(v, alphabet) == "" {
+			return argType
+		}
+	}
+	return 0
+}
+
+// extPacked extracts numChars chars of packed string with type header
+// argType from encoded IKB action d starts from startPos and returns it.
+// If some char is not in the alphabet, false is returned.
+func (d *Encoded) extPacked(argType, startPos, numChars byte) (v string, success bool) {
+
+	alphabet, width := packedAlphabet(argType)
+	mask := uint(1)<<width - 1
+
+	b := make([]byte, numChars)
+	for i := range b {
+
+		// Char may cross the bytes' bound (6 bits width)
+		bit := uint(i) * width
+		pos := int(startPos) + int(bit/8)
+		code := uint(d[pos]) >> (bit % 8)
+		if bit%8+width > 8 {
+			code |= uint(d[pos+1]) << (8 - bit%8)
+		}
+
+		if code &= mask; code >= uint(len(alphabet)) {
+			return "", false
+		}
+		b[i] = alphabet[code]
+	}
+	return string(b), true
+}
+
+// argPutPacked puts string argument v as packed string with type header
+// argType to the encoded IKB action d. All chars of v must be
+// in its alphabet.
+//
+// If it was successfully, returns the index of that argument.
+// Otherwise -1 is returned (argument has not been added).
+func (d *Encoded) argPutPacked(argType byte, v string) (argIdx int) {
+
+	alphabet, width := packedAlphabet(argType)
+	if len(v) > int(^byte(0)) || strings.Trim(v, alphabet) != "" {
+		return cBadIndex
+	}
+
+	numChars := byte(len(v))
+	numBytes := packedLen(argType, numChars)
+	if !d.argHaveFreeBytes(2 + numBytes) {
+		return cBadIndex
+	}
+
+	// Get start pos, update free index for next argument
+	startPos := d[cPosArgsFree]
+	d[cPosArgsFree] += numBytes + 2
+
+	// Save arg type, save number of chars
+	d[startPos+0] = argType
+	d[startPos+1] = numChars
+
+	// Save bits (chars are OR-ed, so bytes are zeroed first)
+	for i := byte(0); i < numBytes; i++ {
+		d[startPos+2+i] = 0
+	}
+	for i := 0; i < len(v); i++ {
+		bit := uint(i) * width
+		pos := int(startPos) + 2 + int(bit/8)
+		code := uint(strings.IndexByte(alphabet, v[i]))
+
+		d[pos] |= byte(code << (bit % 8))
+		if bit%8+width > 8 {
+			d[pos+1] |= byte(code >> (8 - bit%8))
+		}
+	}
+	return d.argCountIncPostfix()
+}
+
+// PutArgStringPacked puts string argument v to the encoded IKB action d
+// as packed string of the densest alphabet that has all chars of v:
+// decimal digits, lowercase hex digits or lowercase alphanumerics.
+// If there is no such alphabet (or packed v doesn't fit), v is put
+// as usual string argument (see PutArgString).
+//
+// Packed string is extracted by GetArgString as usual string.
+//
+// If it was successfully, returns the index of that argument.
+// Otherwise -1 is returned (argument has not been added).
+func (d *Encoded) PutArgStringPacked(v string) (argIdx int) {
+
+	if argType := packedMode(v); argType != 0 {
+		if argIdx = d.argPutPacked(argType, v); argIdx != cBadIndex {
+			return argIdx
+		}
+	}
+	return d.PutArgString(v)
+}
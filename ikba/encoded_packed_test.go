@@ -0,0 +1,111 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package ikba
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPutArgStringPacked(t *testing.T) {
+
+	tests := []struct {
+		v        string
+		argType  byte
+		numBytes int
+	}{
+		{"", cArgTypeDigits, 2},
+		{"0123456789", cArgTypeDigits, 7},
+		{"deadbeef", cArgTypeHex, 6},
+		{"user42", cArgTypeAlnum, 7},
+		{"zzzzzzzzzz", cArgTypeAlnum, 10},
+		{"Mixed-Case", cArgTypeString, 12},
+	}
+
+	for _, test := range tests {
+		d := New()
+		free := d.Free()
+
+		require.Equal(t, 0, d.PutArgStringPacked(test.v), test.v)
+		require.Equal(t, test.argType, d[cPosArgsContent], test.v)
+		require.Equal(t, test.numBytes, free-d.Free(), test.v)
+
+		require.Equal(t, ArgTypeString, d.ArgType(0))
+
+		v, ok := d.GetArgString(0)
+		require.True(t, ok, test.v)
+		require.Equal(t, test.v, v)
+
+		decoded, err := Decode(d.CallbackData())
+		require.NoError(t, err, test.v)
+		v, ok = decoded.GetArgStringAt(0)
+		require.True(t, ok, test.v)
+		require.Equal(t, test.v, v)
+	}
+}
+
+func TestPackedCapacity(t *testing.T) {
+
+	d := New()
+	digits := strings.Repeat("7", 2*(d.Free()-2))
+
+	require.Equal(t, 0, d.PutArgStringPacked(digits))
+	require.Equal(t, 0, d.Free())
+
+	v, ok := d.GetArgString(0)
+	require.True(t, ok)
+	require.Equal(t, digits, v)
+
+	// Packed string that doesn't fit is put as usual string (and doesn't fit too)
+	require.Equal(t, cBadIndex, New().PutArgStringPacked(digits+"7"))
+}
+
+func TestDecodePackedBadChar(t *testing.T) {
+
+	header := []byte{1, 0, 0, 0, 0, 0, 0, 0}
+
+	_, err := Decode(encodeRaw(append(header, 1, 13, cArgTypeDigits, 1, 0x0a)...))
+	require.Equal(t, ErrBadArgValue, err)
+
+	d, err := Decode(encodeRaw(append(header, 1, 13, cArgTypeHex, 1, 0x0a)...))
+	require.NoError(t, err)
+	v, ok := d.GetArgString(0)
+	require.True(t, ok)
+	require.Equal(t, "a", v)
+
+	_, err = Decode(encodeRaw(append(header, 1, 13, cArgTypeDigits, 3, 0x21)...))
+	require.Equal(t, ErrBadArgLength, err)
+}
+
+func TestSetArgPacked(t *testing.T) {
+
+	d := New()
+	d.PutArgStringPacked("user42")
+
+	require.True(t, d.SetArg(0, "xyz789"))
+	require.False(t, d.SetArg(0, "XYZ789"))
+	require.False(t, d.SetArg(0, "xyz7890"))
+
+	v, ok := d.GetArgString(0)
+	require.True(t, ok)
+	require.Equal(t, "xyz789", v)
+}
+
+func TestDumpPacked(t *testing.T) {
+
+	d := New()
+	d.PutArgStringPacked("ff00")
+	d.PutArgStringPacked("2019")
+
+	nodes := d.Dump()
+	require.Equal(t, "Argument (hex string)", nodes[4].Type)
+	require.Equal(t, "ff00", nodes[4].Value)
+	require.Equal(t, cPosArgsContent+2, nodes[4].PosContent)
+
+	require.Equal(t, `{view:0 ssid:0 args:[hex string("ff00") digits string("2019")]}`, d.String())
+}
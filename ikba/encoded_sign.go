@@ -0,0 +1,120 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package ikba
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"sync"
+)
+
+// Signed encoded IKB actions.
+//
+// Any Telegram client can send any callback data, thus user can change
+// view ID, session ID or arguments of encoded IKB action.
+// If the bot's secret is set (see SetSecret), each encoded IKB action
+// is signed by CallbackData: truncated HMAC-SHA256 of used bytes
+// keyed by the secret is appended to them:
+// < Used bytes : N bytes > < Signature : 4 bytes >
+//
+// Decode verifies the signature and returns ErrBadSignature
+// if it doesn't match (callback data is forged or has been signed
+// by another secret).
+//
+// Signature requires 4 bytes, thus arguments of signed encoded IKB action
+// can use 4 bytes less (see Free).
+
+// ErrBadSignature means that signature of callback data doesn't match
+// its content (callback data is forged, unsigned or signed
+// by another secret).
+var ErrBadSignature = errors.New("ikba: bad signature of encoded IKB action")
+
+// Predefined constants of signed encoded IKB action.
+const (
+
+	// Length of the encoded IKB action's signature (truncated HMAC) in bytes.
+	cSignatureLen byte = 4
+)
+
+// signing is the bot's secret that encoded IKB actions are signed by.
+// Signing is disabled if secret is empty.
+var signing = struct {
+	sync.RWMutex
+	secret []byte
+}{}
+
+// SetSecret sets the bot's secret that encoded IKB actions are signed by
+// and verified with. Empty secret disables signing.
+//
+// Call it once at startup before any encoded IKB action is created:
+// signature changes the number of bytes arguments can use,
+// and callback data that has been signed by the old secret
+// (or has not been signed) can't be decoded anymore.
+func SetSecret(secret []byte) {
+
+	signing.Lock()
+	defer signing.Unlock()
+
+	signing.secret = append([]byte(nil), secret...)
+}
+
+// signatureLen returns the number of bytes the signature requires,
+// or 0 if signing is disabled.
+func signatureLen() byte {
+
+	signing.RLock()
+	defer signing.RUnlock()
+
+	if len(signing.secret) == 0 {
+		return 0
+	}
+	return cSignatureLen
+}
+
+// sign appends the signature of data to data and returns it.
+// If signing is disabled, data is returned as is.
+func sign(data []byte) []byte {
+
+	signing.RLock()
+	defer signing.RUnlock()
+
+	if len(signing.secret) == 0 {
+		return data
+	}
+
+	mac := hmac.New(sha256.New, signing.secret)
+	mac.Write(data)
+	return append(data, mac.Sum(nil)[:cSignatureLen]...)
+}
+
+// verify checks the signature at the end of signed data and returns data
+// without it. If signing is disabled, signed is returned as is.
+//
+// If signed is too short or signature doesn't match, ErrBadSignature
+// is returned.
+func verify(signed []byte) ([]byte, error) {
+
+	signing.RLock()
+	defer signing.RUnlock()
+
+	if len(signing.secret) == 0 {
+		return signed, nil
+	}
+
+	if len(signed) < int(cSignatureLen) {
+		return nil, ErrBadSignature
+	}
+	data, signature := signed[:len(signed)-int(cSignatureLen)], signed[len(signed)-int(cSignatureLen):]
+
+	mac := hmac.New(sha256.New, signing.secret)
+	mac.Write(data)
+	if !hmac.Equal(signature, mac.Sum(nil)[:cSignatureLen]) {
+		return nil, ErrBadSignature
+	}
+
+	return data, nil
+}
@@ -0,0 +1,67 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package ikba
+
+import (
+	"encoding/ascii85"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignedDecode(t *testing.T) {
+
+	SetSecret([]byte("secret"))
+	defer SetSecret(nil)
+
+	d := New()
+	d.PutViewID(42)
+	require.Equal(t, int(cPosFreeMax-cPosArgsContent-cSignatureLen), d.Free())
+	require.NotEqual(t, cBadIndex, d.PutArgInt32(100))
+	require.NotEqual(t, cBadIndex, d.PutArgString("item"))
+
+	callbackData := d.CallbackData()
+	decoded, err := Decode(callbackData)
+	require.NoError(t, err)
+	require.Equal(t, d, decoded)
+
+	// Tamper the argument keeping the signature
+	raw := make([]byte, 4*len(callbackData))
+	n, _, err := ascii85.Decode(raw, []byte(callbackData[1:]), true)
+	require.NoError(t, err)
+	raw[cPosArgsContent+1]++
+	_, err = Decode(encodeRaw(raw[:n]...))
+	require.Equal(t, ErrBadSignature, err)
+
+	// Another secret
+	SetSecret([]byte("another secret"))
+	_, err = Decode(callbackData)
+	require.Equal(t, ErrBadSignature, err)
+
+	// Unsigned
+	SetSecret(nil)
+	unsigned := d.CallbackData()
+	SetSecret([]byte("secret"))
+	_, err = Decode(unsigned)
+	require.Equal(t, ErrBadSignature, err)
+}
+
+func TestSignedCapacity(t *testing.T) {
+
+	SetSecret([]byte("secret"))
+	defer SetSecret(nil)
+
+	d := New()
+	d.PutViewID(-1)
+	d.PutSessionID(-1)
+	for d.PutArgInt8(-1) != cBadIndex {
+	}
+	require.Equal(t, 0, d.Free())
+	require.True(t, len(d.CallbackData()) <= cCallbackDataMax)
+
+	_, err := Decode(d.CallbackData())
+	require.NoError(t, err)
+}
@@ -6,7 +6,9 @@
 package ikba
 
 import (
+	"strings"
 	"testing"
+	"unicode/utf8"
 	"unsafe"
 
 	"github.com/qioalice/devola/core/chat"
@@ -40,3 +42,98 @@ func TestConsts(t *testing.T) {
 		"Incompatible size of chat.SessionID type and this package's constants.",
 	)
 }
+
+func TestDecode(t *testing.T) {
+
+	d := New()
+	d.PutViewID(view.IDEnc(42))
+	d.PutSessionID(chat.SessionID(7))
+	require.Equal(t, 0, d.PutArgInt16(-3))
+	require.Equal(t, 1, d.PutArgString("hello"))
+
+	decoded, err := Decode(d.CallbackData())
+	require.NoError(t, err)
+	require.Equal(t, d, decoded)
+	require.Equal(t, view.IDEnc(42), decoded.GetViewID())
+	require.Equal(t, chat.SessionID(7), decoded.GetSessionID())
+
+	_, err = Decode("")
+	require.Equal(t, ErrBadLength, err)
+
+	_, err = Decode(d.CallbackData()[:15])
+	require.Equal(t, ErrBadLayout, err)
+
+	_, err = Decode("zzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzz")
+	require.Equal(t, ErrBadLength, err)
+
+	_, err = Decode("zz{}")
+	require.Equal(t, ErrBadEncoding, err)
+}
+
+func TestCallbackDataCapacity(t *testing.T) {
+
+	require.Equal(t, cCallbackDataMax, 1+encodedLen(int(cPosFreeMax)))
+	require.True(t, 1+encodedLen(int(cPosFreeMax)+1) > cCallbackDataMax)
+	require.Equal(t, cCallbackDataMax, encodedLen(int(cPosFreeMaxLegacy)))
+
+	d := New()
+	d.PutViewID(view.IDEnc(-1))
+	d.PutSessionID(chat.SessionID(-1))
+	require.Equal(t, int(cPosFreeMax-cPosArgsContent), d.Free())
+
+	// Fill all free bytes by string with max 0xFF bytes (the worst case)
+	require.Equal(t, cBadIndex, d.PutArgString(strings.Repeat("\xFF", d.Free()-1)))
+	require.Equal(t, 0, d.PutArgString(strings.Repeat("\xFF", d.Free()-2)))
+	require.Equal(t, 0, d.Free())
+	require.Equal(t, cBadIndex, d.PutArgInt8(1))
+
+	callbackData := d.CallbackData()
+	require.Len(t, callbackData, cCallbackDataMax)
+	require.True(t, utf8.ValidString(callbackData))
+
+	decoded, err := Decode(callbackData)
+	require.NoError(t, err)
+	require.Equal(t, d, decoded)
+
+	// Too long string must not corrupt memory
+	require.Equal(t, cBadIndex, New().PutArgString(strings.Repeat("a", 300)))
+}
+
+func TestGetArgNegativeIndex(t *testing.T) {
+
+	d := New()
+	require.Equal(t, 0, d.PutArgInt32(1))
+	require.Equal(t, 1, d.PutArgInt16(2))
+	require.Equal(t, 2, d.PutArgString("page"))
+	require.Equal(t, 3, d.PutArgInt8(3))
+	require.Equal(t, 4, d.PutArgInt8(4))
+
+	v8, ok := d.GetArgInt8(-1)
+	require.True(t, ok)
+	require.Equal(t, int8(4), v8)
+
+	v8, ok = d.GetArgInt8(-2)
+	require.True(t, ok)
+	require.Equal(t, int8(3), v8)
+
+	v8, ok = d.GetArgInt8(3)
+	require.True(t, ok)
+	require.Equal(t, int8(3), v8)
+
+	s, ok := d.GetArgString(-3)
+	require.True(t, ok)
+	require.Equal(t, "page", s)
+
+	v32, ok := d.GetArgInt32(-5)
+	require.True(t, ok)
+	require.Equal(t, int32(1), v32)
+
+	_, ok = d.GetArgInt32(-6)
+	require.False(t, ok)
+
+	_, ok = d.GetArgInt32(5)
+	require.False(t, ok)
+
+	_, ok = d.GetArgString(-2)
+	require.False(t, ok)
+}
@@ -0,0 +1,83 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package ikba
+
+import (
+	"errors"
+)
+
+// Versioned format.
+//
+// Buttons live in chat history forever, thus callback data of any
+// format version that has ever been sent must be decoded (or rejected
+// with recognisable error).
+//
+// Callback data starts from the format version marker: one char that
+// can't be generated by Ascii85 ('v' is version 2, 'w' is version 3, etc,
+// up to 'y'). Callback data without marker is version 1 (it has been
+// generated before the marker has been introduced).
+//
+// Decode understands all known versions. Callback data of unknown
+// (newer) version is rejected with ErrUnknownVersion.
+// Version marker requires 1 char, thus arguments can use 1 byte less
+// than in version 1.
+//
+// Versions:
+// 1 - no marker, arguments can use 41 bytes (next free position is 51);
+// 2 - 'v' marker, arguments can use 40 bytes;
+// 3 - 'w' marker, expiry (see SetExpiry) and packed strings
+// (see PutArgStringPacked) are allowed.
+//
+// To change the format: increase Version, keep the decoding of the old
+// versions in Decode. New type headers change the format too, even if
+// the layout is the same: the old decoder rejects unknown type header
+// with ErrBadArgType (as malformed callback data), but it must reject
+// callback data of newer version with ErrUnknownVersion.
+
+// ErrUnknownVersion means that callback data has format version marker
+// of unknown version (it has been generated by newer version of package).
+var ErrUnknownVersion = errors.New("ikba: unknown format version of encoded IKB action")
+
+// Version is the format version of callback data generated by CallbackData.
+const Version = 3
+
+// Predefined constants of versioned format.
+const (
+
+	// Format version of callback data without version marker.
+	cVersionLegacy = 1
+
+	// Format version of callback data with the first version marker.
+	// It has the same layout as Version, but without expiry
+	// and packed strings.
+	cVersionMarked = 2
+
+	// Version marker of version 2. Next versions have next chars.
+	cVersionMarkerMin byte = 'v'
+
+	// Version marker of the max version that can be marked.
+	cVersionMarkerMax byte = 'y'
+
+	// Max allowable next free position of version 1
+	// (there is no version marker).
+	cPosFreeMaxLegacy byte = 51
+)
+
+// versionOf returns the format version of callback data s
+// and callback data without version marker.
+func versionOf(s string) (version int, data string) {
+
+	if s != "" && s[0] >= cVersionMarkerMin && s[0] <= cVersionMarkerMax {
+		return int(s[0]-cVersionMarkerMin) + 2, s[1:]
+	}
+	return cVersionLegacy, s
+}
+
+// versionMarker returns the format version marker of version version
+// (that must be 2 or greater).
+func versionMarker(version int) byte {
+	return cVersionMarkerMin + byte(version-2)
+}
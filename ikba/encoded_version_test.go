@@ -0,0 +1,72 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package ikba
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersion(t *testing.T) {
+
+	d := New()
+	d.PutViewID(42)
+	d.PutArgString("foo")
+
+	callbackData := d.CallbackData()
+	require.Equal(t, byte('w'), callbackData[0])
+
+	decoded, err := Decode(callbackData)
+	require.NoError(t, err)
+	require.Equal(t, d, decoded)
+
+	// Version 2 has the same layout
+	decoded, err = Decode("v" + callbackData[1:])
+	require.NoError(t, err)
+	require.Equal(t, d, decoded)
+
+	// Unknown (newer) version
+	_, err = Decode("x" + callbackData[1:])
+	require.Equal(t, ErrUnknownVersion, err)
+	_, err = Decode("y" + callbackData[1:])
+	require.Equal(t, ErrUnknownVersion, err)
+}
+
+func TestVersionLegacy(t *testing.T) {
+
+	// Version 1 callback data uses all 51 bytes (no version marker)
+	raw := []byte{42, 1, 1, 1, 7, 1, 1, 1, 1, 51, cArgTypeString, 39}
+	raw = append(raw, strings.Repeat("a", 39)...)
+	legacy := encodeRaw(raw...)
+	require.Len(t, legacy, cCallbackDataMax)
+
+	d, err := Decode(legacy)
+	require.NoError(t, err)
+	v, ok := d.GetArgStringAt(0)
+	require.True(t, ok)
+	require.Equal(t, strings.Repeat("a", 39), v)
+
+	// It's encoded as version 1 again
+	require.Equal(t, legacy, d.CallbackData())
+
+	// Marked versions have 1 byte less, they can't have 51 bytes.
+	// Zero group is encoded to 1 char ('z'), thus marked callback data
+	// with 51 bytes is not too long itself
+	raw = []byte{42, 1, 1, 1, 7, 1, 1, 1, 1, 51, cArgTypeString, 39}
+	raw = append(raw, 0, 0, 0, 0)
+	raw = append(raw, strings.Repeat("a", 35)...)
+	zeroed := encodeRaw(raw...)
+	require.True(t, len("v"+zeroed) <= cCallbackDataMax)
+
+	_, err = Decode(zeroed)
+	require.NoError(t, err)
+	_, err = Decode("v" + zeroed)
+	require.Equal(t, ErrBadLength, err)
+	_, err = Decode("w" + zeroed)
+	require.Equal(t, ErrBadLength, err)
+}
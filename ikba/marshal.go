@@ -0,0 +1,552 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package ikba
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Struct (un)marshalling.
+//
+// Marshal and Unmarshal use struct tags to pick the index and the type
+// of encoded argument each struct field is stored to:
+//
+//	type Item struct {
+//		ID    uint16 `ikba:"0,uint16"`
+//		Name  string `ikba:"1"`
+//		Price int64  `ikba:"2,int32"`
+//	}
+//
+// The type may be omitted, then it's picked by field's type
+// (int and uint are stored as varint and uvarint, bool as bool,
+// []bool as bit-packed bools, []byte as bytes).
+// Indexes of fields must be 0, 1, 2, ... without gaps.
+// Fields without tag (or with "-" tag) are ignored.
+
+// Errors that can be returned by Marshal or Unmarshal.
+// Errors about some field are returned as *FieldError.
+var (
+
+	// ErrNotStruct means that value is not a struct (or pointer to struct
+	// for Unmarshal).
+	ErrNotStruct = errors.New("ikba: value is not a struct")
+
+	// ErrBadTag means that struct field's ikba tag is malformed,
+	// has unknown type, the type doesn't match field's kind,
+	// or indexes of fields have gaps or duplicates.
+	ErrBadTag = errors.New("ikba: bad ikba tag")
+
+	// ErrNoSpace means that encoded IKB action has no space for field.
+	ErrNoSpace = errors.New("ikba: no space left in encoded IKB action")
+
+	// ErrOverflow means that field's value doesn't fit the argument's type
+	// (or vice versa for Unmarshal).
+	ErrOverflow = errors.New("ikba: value overflows argument's type")
+
+	// ErrArgMissing means that encoded IKB action has no argument for field.
+	ErrArgMissing = errors.New("ikba: no such argument")
+
+	// ErrArgType means that encoded argument has not the type of field's tag.
+	ErrArgType = errors.New("ikba: argument has another type")
+)
+
+// FieldError is an error about some struct field
+// that is returned by Marshal or Unmarshal.
+type FieldError struct {
+
+	// Field is the name of struct field.
+	Field string
+
+	// Err is one of ErrBadTag, ErrNoSpace, ErrOverflow, ErrArgMissing,
+	// ErrArgType errors.
+	Err error
+}
+
+// Error returns a string representation of field error e.
+func (e *FieldError) Error() string {
+	return "ikba: field " + e.Field + ": " + strings.TrimPrefix(e.Err.Error(), "ikba: ")
+}
+
+// Unwrap returns the cause of field error e.
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// marshalField is a struct field that is (un)marshalled to the argument.
+type marshalField struct {
+	name     string
+	fieldIdx int
+	argIdx   int
+	argType  byte
+}
+
+// argTypeByName returns the type header of argument's type with name name,
+// or 0 if there is no such type.
+func argTypeByName(name string) byte {
+
+	for argType := cArgTypeInt8; argType <= cArgTypeBytes; argType++ {
+		if argType != cArgTypeKey && argType != cArgTypeTrue &&
+			(*Encoded)(nil).argType2S(argType) == name {
+			return argType
+		}
+	}
+	return 0
+}
+
+// argTypeByType returns the type header of argument that field
+// of type typ is stored to by default, or 0 if typ is not supported.
+func argTypeByType(typ reflect.Type) byte {
+
+	switch typ.Kind() {
+
+	case reflect.Int8:
+		return cArgTypeInt8
+
+	case reflect.Int16:
+		return cArgTypeInt16
+
+	case reflect.Int32:
+		return cArgTypeInt32
+
+	case reflect.Int64:
+		return cArgTypeInt64
+
+	case reflect.Int:
+		return cArgTypeVarint
+
+	case reflect.Uint8:
+		return cArgTypeUint8
+
+	case reflect.Uint16:
+		return cArgTypeUint16
+
+	case reflect.Uint32:
+		return cArgTypeUint32
+
+	case reflect.Uint64:
+		return cArgTypeUint64
+
+	case reflect.Uint:
+		return cArgTypeUvarint
+
+	case reflect.Float32:
+		return cArgTypeFloat32
+
+	case reflect.Float64:
+		return cArgTypeFloat64
+
+	case reflect.String:
+		return cArgTypeString
+
+	case reflect.Bool:
+		return cArgTypeFalse
+	}
+
+	switch typeClass(typ) {
+
+	case 'y':
+		return cArgTypeBytes
+
+	case 'B':
+		return cArgTypeBools
+
+	default:
+		return 0
+	}
+}
+
+// argTypeZero returns the zero value of Go type of argument
+// with type header argType.
+func argTypeZero(argType byte) reflect.Value {
+
+	switch argType {
+
+	case cArgTypeInt8:
+		return reflect.ValueOf(int8(0))
+
+	case cArgTypeInt16:
+		return reflect.ValueOf(int16(0))
+
+	case cArgTypeInt32:
+		return reflect.ValueOf(int32(0))
+
+	case cArgTypeInt64:
+		return reflect.ValueOf(int64(0))
+
+	case cArgTypeUint8:
+		return reflect.ValueOf(uint8(0))
+
+	case cArgTypeUint16:
+		return reflect.ValueOf(uint16(0))
+
+	case cArgTypeUint32:
+		return reflect.ValueOf(uint32(0))
+
+	case cArgTypeUint64:
+		return reflect.ValueOf(uint64(0))
+
+	case cArgTypeFloat32:
+		return reflect.ValueOf(float32(0))
+
+	case cArgTypeFloat64:
+		return reflect.ValueOf(float64(0))
+
+	case cArgTypeVarint:
+		return reflect.ValueOf(int64(0))
+
+	case cArgTypeUvarint:
+		return reflect.ValueOf(uint64(0))
+
+	case cArgTypeFalse:
+		return reflect.ValueOf(false)
+
+	case cArgTypeBools:
+		return reflect.ValueOf([]bool(nil))
+
+	case cArgTypeBytes:
+		return reflect.ValueOf([]byte(nil))
+
+	default:
+		return reflect.ValueOf("")
+	}
+}
+
+// typeClass returns the class of type typ: 'i' for signed integers,
+// 'u' for unsigned integers, 'f' for floats, 's' for strings,
+// 'b' for bools, 'B' for bool slices, 'y' for byte slices and 0 for others.
+func typeClass(typ reflect.Type) byte {
+
+	switch typ.Kind() {
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return 'i'
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return 'u'
+
+	case reflect.Float32, reflect.Float64:
+		return 'f'
+
+	case reflect.String:
+		return 's'
+
+	case reflect.Bool:
+		return 'b'
+
+	case reflect.Slice:
+		switch typ.Elem().Kind() {
+
+		case reflect.Uint8:
+			return 'y'
+
+		case reflect.Bool:
+			return 'B'
+		}
+	}
+
+	return 0
+}
+
+// isIntClass reports whether class of type (see typeClass) is integer.
+func isIntClass(class byte) bool {
+	return class == 'i' || class == 'u'
+}
+
+// typeCompatible reports whether field of type typ can be stored
+// to the argument with type header argType: integers can be stored
+// to any integer type (if value fits), floats to any float type,
+// others to the argument of the same type only.
+func typeCompatible(typ reflect.Type, argType byte) bool {
+
+	if argType == 0 {
+		return false
+	}
+
+	fieldClass := typeClass(typ)
+	argClass := typeClass(argTypeZero(argType).Type())
+
+	return fieldClass != 0 && (fieldClass == argClass ||
+		isIntClass(fieldClass) && isIntClass(argClass))
+}
+
+// intFits reports whether the value of signed or unsigned integer v
+// fits the signed or unsigned integer type of dst.
+func intFits(v, dst reflect.Value) bool {
+
+	switch {
+
+	case typeClass(v.Type()) == 'i' && typeClass(dst.Type()) == 'i':
+		return !dst.OverflowInt(v.Int())
+
+	case typeClass(v.Type()) == 'i':
+		return v.Int() >= 0 && !dst.OverflowUint(uint64(v.Int()))
+
+	case typeClass(dst.Type()) == 'u':
+		return !dst.OverflowUint(v.Uint())
+
+	default:
+		return v.Uint() <= 1<<63-1 && !dst.OverflowInt(int64(v.Uint()))
+	}
+}
+
+// marshalFields returns the tagged fields of struct type typ
+// ordered by their arguments' indexes.
+func marshalFields(typ reflect.Type) ([]marshalField, error) {
+
+	var fields []marshalField
+	for i := 0; i < typ.NumField(); i++ {
+
+		sf := typ.Field(i)
+		tag, found := sf.Tag.Lookup("ikba")
+		if !found || tag == "-" {
+			continue
+		}
+
+		field := marshalField{name: sf.Name, fieldIdx: i}
+		badTag := &FieldError{Field: sf.Name, Err: ErrBadTag}
+
+		// Only exported fields can be set
+		if sf.PkgPath != "" {
+			return nil, badTag
+		}
+
+		parts := strings.Split(tag, ",")
+		if len(parts) > 2 {
+			return nil, badTag
+		}
+
+		argIdx, err := strconv.Atoi(parts[0])
+		if err != nil || argIdx < 0 {
+			return nil, badTag
+		}
+		field.argIdx = argIdx
+
+		field.argType = argTypeByType(sf.Type)
+		if len(parts) == 2 {
+			field.argType = argTypeByName(parts[1])
+		}
+
+		if !typeCompatible(sf.Type, field.argType) {
+			return nil, badTag
+		}
+
+		fields = append(fields, field)
+	}
+
+	sort.SliceStable(fields, func(i, j int) bool {
+		return fields[i].argIdx < fields[j].argIdx
+	})
+
+	for i := range fields {
+		if fields[i].argIdx != i {
+			return nil, &FieldError{Field: fields[i].name, Err: ErrBadTag}
+		}
+	}
+
+	return fields, nil
+}
+
+// Marshal creates a new encoded IKB action and puts tagged fields
+// of struct (or pointer to struct) v to it as arguments
+// (see the package's "Struct (un)marshalling" notes).
+//
+// View ID and session ID are not set, use PutViewID, PutSessionID.
+//
+// If some field can't be put (no space left, value overflows
+// the argument's type, bad tag), *FieldError is returned.
+func Marshal(v interface{}) (*Encoded, error) {
+
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return nil, ErrNotStruct
+	}
+
+	fields, err := marshalFields(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	d := New()
+	for _, field := range fields {
+		if err := d.putField(field, rv.Field(field.fieldIdx)); err != nil {
+			return nil, &FieldError{Field: field.name, Err: err}
+		}
+	}
+
+	return d, nil
+}
+
+// putField puts the value fv of field to the encoded IKB action d.
+func (d *Encoded) putField(field marshalField, fv reflect.Value) error {
+
+	switch typeClass(fv.Type()) {
+
+	case 'i', 'u':
+		if !intFits(fv, argTypeZero(field.argType)) {
+			return ErrOverflow
+		}
+
+	case 'f':
+		if argTypeZero(field.argType).OverflowFloat(fv.Float()) {
+			return ErrOverflow
+		}
+	}
+
+	var argIdx int
+	switch field.argType {
+
+	case cArgTypeInt8:
+		argIdx = d.PutArgInt8(int8(intValue(fv)))
+
+	case cArgTypeInt16:
+		argIdx = d.PutArgInt16(int16(intValue(fv)))
+
+	case cArgTypeInt32:
+		argIdx = d.PutArgInt32(int32(intValue(fv)))
+
+	case cArgTypeInt64:
+		argIdx = d.PutArgInt64(intValue(fv))
+
+	case cArgTypeUint8:
+		argIdx = d.PutArgUint8(uint8(intValue(fv)))
+
+	case cArgTypeUint16:
+		argIdx = d.PutArgUint16(uint16(intValue(fv)))
+
+	case cArgTypeUint32:
+		argIdx = d.PutArgUint32(uint32(intValue(fv)))
+
+	case cArgTypeUint64:
+		argIdx = d.PutArgUint64(uint64(intValue(fv)))
+
+	case cArgTypeFloat32:
+		argIdx = d.PutArgFloat32(float32(fv.Float()))
+
+	case cArgTypeFloat64:
+		argIdx = d.PutArgFloat64(fv.Float())
+
+	case cArgTypeString:
+		argIdx = d.PutArgString(fv.String())
+
+	case cArgTypeVarint:
+		argIdx = d.PutArgVarint(intValue(fv))
+
+	case cArgTypeUvarint:
+		argIdx = d.PutArgUvarint(uint64(intValue(fv)))
+
+	case cArgTypeFalse:
+		argIdx = d.PutArgBool(fv.Bool())
+
+	case cArgTypeBools:
+		argIdx = d.PutArgBools(fv.Convert(reflect.TypeOf([]bool(nil))).Interface().([]bool)...)
+
+	case cArgTypeBytes:
+		argIdx = d.PutArgBytes(fv.Bytes())
+	}
+
+	if argIdx == cBadIndex {
+		return ErrNoSpace
+	}
+	return nil
+}
+
+// intValue returns the value of signed or unsigned integer value v
+// as int64 (unsigned values are converted with wrapping).
+func intValue(v reflect.Value) int64 {
+
+	if typeClass(v.Type()) == 'u' {
+		return int64(v.Uint())
+	}
+	return v.Int()
+}
+
+// Unmarshal extracts arguments of encoded IKB action d to the tagged fields
+// of struct v points to (see the package's "Struct (un)marshalling" notes).
+//
+// Arguments are extracted by exact indexes (see GetArgAt) and each
+// argument must have exactly the type of field's tag.
+//
+// If some field can't be set (no argument, argument has another type,
+// value overflows field, bad tag, stored argument has expired),
+// *FieldError is returned.
+func Unmarshal(d *Encoded, v interface{}) error {
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return ErrNotStruct
+	}
+	rv = rv.Elem()
+
+	fields, err := marshalFields(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	for _, field := range fields {
+		if err := d.getField(field, rv.Field(field.fieldIdx)); err != nil {
+			return &FieldError{Field: field.name, Err: err}
+		}
+	}
+
+	return nil
+}
+
+// getField extracts the argument of field from the encoded IKB action d
+// to the field's value fv.
+func (d *Encoded) getField(field marshalField, fv reflect.Value) error {
+
+	argType := d.ArgType(field.argIdx)
+	switch {
+
+	case argType == ArgTypeNone:
+		return ErrArgMissing
+
+	case byte(argType) != field.argType:
+		return ErrArgType
+	}
+
+	// Stored argument may be expired (or evicted) after Decode
+	arg, success := d.GetArgAt(field.argIdx)
+	if !success {
+		return ErrExpired
+	}
+	av := reflect.ValueOf(arg)
+
+	switch typeClass(av.Type()) {
+
+	case 'i', 'u':
+		if !intFits(av, fv) {
+			return ErrOverflow
+		}
+		if typeClass(fv.Type()) == 'u' {
+			fv.SetUint(uint64(intValue(av)))
+		} else {
+			fv.SetInt(intValue(av))
+		}
+
+	case 'f':
+		if fv.OverflowFloat(av.Float()) {
+			return ErrOverflow
+		}
+		fv.SetFloat(av.Float())
+
+	case 's':
+		fv.SetString(av.String())
+
+	case 'b':
+		fv.SetBool(av.Bool())
+
+	default:
+		// []bool, []byte: named slice types are allowed too
+		fv.Set(av.Convert(fv.Type()))
+	}
+
+	return nil
+}
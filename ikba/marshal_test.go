@@ -0,0 +1,92 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package ikba
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type marshalItem struct {
+	Name    string  `ikba:"1"`
+	ID      uint16  `ikba:"0,uint16"`
+	Price   int64   `ikba:"2,int32"`
+	Rate    float64 `ikba:"3,float32"`
+	Ignored int
+	Skipped int `ikba:"-"`
+}
+
+func TestMarshal(t *testing.T) {
+
+	item := marshalItem{Name: "apple", ID: 7, Price: -100, Rate: 0.5, Ignored: 1}
+
+	d, err := Marshal(&item)
+	require.NoError(t, err)
+	require.Equal(t, 4, d.ArgCount())
+	require.Equal(t, ArgTypeUint16, d.ArgType(0))
+	require.Equal(t, ArgTypeString, d.ArgType(1))
+	require.Equal(t, ArgTypeInt32, d.ArgType(2))
+	require.Equal(t, ArgTypeFloat32, d.ArgType(3))
+
+	var decoded marshalItem
+	require.NoError(t, Unmarshal(d, &decoded))
+	item.Ignored = 0
+	require.Equal(t, item, decoded)
+}
+
+func TestMarshalErrors(t *testing.T) {
+
+	_, err := Marshal(1)
+	require.Equal(t, ErrNotStruct, err)
+
+	_, err = Marshal(struct {
+		A int `ikba:"0"`
+		B int `ikba:"2"`
+	}{})
+	require.Equal(t, &FieldError{Field: "B", Err: ErrBadTag}, err)
+
+	_, err = Marshal(struct {
+		A string `ikba:"0,int8"`
+	}{})
+	require.Equal(t, &FieldError{Field: "A", Err: ErrBadTag}, err)
+
+	_, err = Marshal(struct {
+		A int `ikba:"0,uint8"`
+	}{A: -1})
+	require.Equal(t, &FieldError{Field: "A", Err: ErrOverflow}, err)
+
+	_, err = Marshal(struct {
+		A string `ikba:"0"`
+		B string `ikba:"1"`
+	}{A: "012345678901234567890123456789012345", B: "abc"})
+	require.Equal(t, &FieldError{Field: "B", Err: ErrNoSpace}, err)
+	require.Equal(t, "ikba: field B: no space left in encoded IKB action", err.Error())
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+
+	d := New()
+	d.PutArgInt64(1000)
+
+	var v struct {
+		A int8 `ikba:"0,int64"`
+	}
+	require.Equal(t, ErrNotStruct, Unmarshal(d, v))
+	require.Equal(t, &FieldError{Field: "A", Err: ErrOverflow}, Unmarshal(d, &v))
+
+	var w struct {
+		A int64 `ikba:"0,int32"`
+	}
+	require.Equal(t, &FieldError{Field: "A", Err: ErrArgType}, Unmarshal(d, &w))
+
+	var x struct {
+		A int64 `ikba:"0"`
+		B int64 `ikba:"1"`
+	}
+	require.Equal(t, &FieldError{Field: "B", Err: ErrArgMissing}, Unmarshal(d, &x))
+	require.Equal(t, int64(1000), x.A)
+}
@@ -0,0 +1,174 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package ikba
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// Stored arguments.
+//
+// Encoded IKB action can't be longer than 64 bytes (and Telegram callback
+// data too), thus long strings (filters, search queries) can't be encoded.
+// If the store is set (see SetStore), string or bytes argument
+// that doesn't fit is kept in the store and only its key is encoded:
+// < Stored header : 1 byte > < Key : 8 bytes >
+//
+// Stored argument is transparent: it has the type of original argument
+// (see ArgType) and it's extracted by GetArgString (GetArgBytes, etc)
+// from the store. Stored argument expires after store's TTL,
+// Decode returns ErrExpired for encoded IKB action with expired
+// stored argument.
+//
+// Store is a local storage: bot instances that don't share store
+// can't decode stored arguments of each other.
+
+// ErrExpired means that stored argument of encoded IKB action has expired
+// (or has been evicted, or store is not set).
+var ErrExpired = errors.New("ikba: stored argument has expired")
+
+// Store is the storage of stored arguments' values.
+// Implementations must be safe for concurrent use.
+//
+// More info: MemoryStore, FileStore.
+type Store interface {
+
+	// Put saves value with key key that expires after ttl.
+	Put(key uint64, value []byte, ttl time.Duration) error
+
+	// Get returns value with key key.
+	// If there is no such value or it has expired, ErrExpired is returned.
+	Get(key uint64) ([]byte, error)
+}
+
+// Predefined constants of stored arguments.
+const (
+
+	// Length of stored argument's key in bytes.
+	cStoredKeyLen byte = 8
+)
+
+// storage is the store (and TTL of stored values) for stored arguments.
+// Storing is disabled if store is nil.
+var storage = struct {
+	sync.RWMutex
+	store Store
+	ttl   time.Duration
+}{}
+
+// SetStore sets the store that string and bytes arguments that don't fit
+// encoded IKB action are kept in for ttl. Nil store disables storing.
+//
+// Call it once at startup. Encoded IKB actions with stored arguments
+// can't be decoded without the same store.
+func SetStore(store Store, ttl time.Duration) {
+
+	storage.Lock()
+	defer storage.Unlock()
+
+	storage.store, storage.ttl = store, ttl
+}
+
+// storePut saves value to the store and returns its key.
+// If store is not set or it can't save value, false is returned.
+func storePut(value []byte) (key uint64, success bool) {
+
+	storage.RLock()
+	defer storage.RUnlock()
+
+	if storage.store == nil {
+		return 0, false
+	}
+
+	var buf [cStoredKeyLen]byte
+	if _, err := io.ReadFull(rand.Reader, buf[:]); err != nil {
+		return 0, false
+	}
+	key = binary.LittleEndian.Uint64(buf[:])
+
+	if err := storage.store.Put(key, append([]byte(nil), value...), storage.ttl); err != nil {
+		return 0, false
+	}
+	return key, true
+}
+
+// storeGet returns the value with key key from the store.
+// If store is not set or value has expired, ErrExpired is returned.
+func storeGet(key uint64) ([]byte, error) {
+
+	storage.RLock()
+	defer storage.RUnlock()
+
+	if storage.store == nil {
+		return nil, ErrExpired
+	}
+	return storage.store.Get(key)
+}
+
+// argPutStored puts value to the store and the stored argument
+// with type header argType and its key to the encoded IKB action d.
+func (d *Encoded) argPutStored(argType byte, value []byte) (argIdx int) {
+
+	// Don't put value to the store if key can't be saved anyway
+	if !d.argHaveFreeBytes(d.argNeedForType(argType)) {
+		return cBadIndex
+	}
+
+	key, success := storePut(value)
+	if !success {
+		return cBadIndex
+	}
+
+	startPos := d.argReserveForType(argType)
+	d.put8bytes(startPos, int64(key))
+	return d.argCountIncPostfix()
+}
+
+// argSetStored puts value to the store and replaces the key of stored
+// argument which type header is at headerPos by the new key.
+// The old value is not changed. Returns false if value can't be saved.
+func (d *Encoded) argSetStored(headerPos byte, value []byte) (success bool) {
+
+	key, success := storePut(value)
+	if success {
+		d.put8bytes(headerPos+1, int64(key))
+	}
+	return success
+}
+
+// argStored returns the value of stored argument which key starts
+// from startPos. If it has expired, nil and false is returned.
+func (d *Encoded) argStored(startPos byte) (v []byte, success bool) {
+
+	v, err := storeGet(uint64(d.ext8bytes(startPos)))
+	return v, err == nil
+}
+
+// checkStored checks that values of all stored arguments
+// of encoded IKB action d are available.
+// Otherwise ErrExpired is returned, or the error of the store as is
+// if it fails (I/O error, etc), thus store's failures are not reported
+// as expired arguments.
+func (d *Encoded) checkStored() error {
+
+	pos := d.argsStart()
+	for i, n := 0, d.ArgCount(); i < n && pos != cPosErr; i++ {
+		headerPos := d.argSkipKey(pos)
+		if argType := d[headerPos]; argType == cArgTypeStoredString || argType == cArgTypeStoredBytes {
+			if _, err := storeGet(uint64(d.ext8bytes(headerPos + 1))); err != nil {
+				return err
+			}
+		}
+		pos = d.argNextFromPos(pos)
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package ikba
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// FileStore is a file-backed Store of stored arguments' values.
+// Each value is a file in the store's directory:
+// < Deadline (Unix nanoseconds) : 8 bytes > < Value : N bytes >
+//
+// Thus values survive the bot's restart. Expired values are removed
+// on access, use Purge to remove all expired values.
+//
+// More info: Store, SetStore.
+type FileStore struct {
+	dir string
+
+	// Current time getter (replaced in tests).
+	now func() time.Time
+}
+
+// NewFileStore creates a new FileStore that keeps values in directory dir
+// (it's created if it doesn't exist) and returns it.
+func NewFileStore(dir string) (*FileStore, error) {
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir, now: time.Now}, nil
+}
+
+// path returns the path of file of value with key key.
+func (s *FileStore) path(key uint64) string {
+	return filepath.Join(s.dir, strconv.FormatUint(key, 16)+".ikba")
+}
+
+// Put saves value with key key that expires after ttl.
+// Value is written to the temporary file that then is renamed,
+// thus Get never reads partially written value.
+func (s *FileStore) Put(key uint64, value []byte, ttl time.Duration) error {
+
+	data := make([]byte, 8, 8+len(value))
+	binary.LittleEndian.PutUint64(data, uint64(s.now().Add(ttl).UnixNano()))
+	data = append(data, value...)
+
+	tmp, err := ioutil.TempFile(s.dir, "tmp")
+	if err != nil {
+		return err
+	}
+
+	if _, err = tmp.Write(data); err == nil {
+		err = tmp.Close()
+	} else {
+		tmp.Close()
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.path(key))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+
+	return err
+}
+
+// Get returns value with key key.
+// If there is no such value or it has expired, ErrExpired is returned.
+func (s *FileStore) Get(key uint64) ([]byte, error) {
+
+	data, err := ioutil.ReadFile(s.path(key))
+	switch {
+
+	case os.IsNotExist(err):
+		return nil, ErrExpired
+
+	case err != nil:
+		return nil, err
+
+	case s.expired(data):
+		os.Remove(s.path(key))
+		return nil, ErrExpired
+	}
+
+	return data[8:], nil
+}
+
+// Purge removes all expired values from store s.
+func (s *FileStore) Purge() error {
+
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.ikba"))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		if data, err := ioutil.ReadFile(path); err == nil && s.expired(data) {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// expired reports whether value's file content data is expired (or malformed).
+func (s *FileStore) expired(data []byte) bool {
+	return len(data) < 8 ||
+		s.now().UnixNano() >= int64(binary.LittleEndian.Uint64(data))
+}
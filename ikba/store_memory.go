@@ -0,0 +1,114 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package ikba
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// MemoryStore is an in-memory Store of stored arguments' values.
+// It keeps up to capacity values, the least recently used value
+// is evicted when it's full. Expired values are removed on access.
+//
+// More info: Store, SetStore.
+type MemoryStore struct {
+	mu       sync.Mutex
+	capacity int
+
+	// Values' list (front is the most recently used) and index.
+	lru     *list.List
+	entries map[uint64]*list.Element
+
+	// Current time getter (replaced in tests).
+	now func() time.Time
+}
+
+// memoryEntry is the value of MemoryStore.
+type memoryEntry struct {
+	key      uint64
+	value    []byte
+	deadline time.Time
+}
+
+// NewMemoryStore creates a new MemoryStore that keeps up to capacity values
+// and returns it. Capacity less than 1 is treated as 1.
+func NewMemoryStore(capacity int) *MemoryStore {
+
+	if capacity < 1 {
+		capacity = 1
+	}
+
+	return &MemoryStore{
+		capacity: capacity,
+		lru:      list.New(),
+		entries:  make(map[uint64]*list.Element),
+		now:      time.Now,
+	}
+}
+
+// Put saves value with key key that expires after ttl.
+// If store is full, the least recently used value is evicted.
+func (s *MemoryStore) Put(key uint64, value []byte, ttl time.Duration) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := &memoryEntry{key: key, value: value, deadline: s.now().Add(ttl)}
+
+	if elem, found := s.entries[key]; found {
+		elem.Value = entry
+		s.lru.MoveToFront(elem)
+		return nil
+	}
+
+	s.entries[key] = s.lru.PushFront(entry)
+
+	for s.lru.Len() > s.capacity {
+		s.remove(s.lru.Back())
+	}
+
+	return nil
+}
+
+// Get returns value with key key.
+// If there is no such value or it has expired, ErrExpired is returned.
+func (s *MemoryStore) Get(key uint64) ([]byte, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, found := s.entries[key]
+	if !found {
+		return nil, ErrExpired
+	}
+
+	entry := elem.Value.(*memoryEntry)
+	if !s.now().Before(entry.deadline) {
+		s.remove(elem)
+		return nil, ErrExpired
+	}
+
+	s.lru.MoveToFront(elem)
+	return append([]byte(nil), entry.value...), nil
+}
+
+// Len returns the number of values in store s (including expired ones
+// that have not been removed yet).
+func (s *MemoryStore) Len() int {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.lru.Len()
+}
+
+// remove removes value elem from store s.
+func (s *MemoryStore) remove(elem *list.Element) {
+	delete(s.entries, elem.Value.(*memoryEntry).key)
+	s.lru.Remove(elem)
+}
@@ -0,0 +1,165 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package ikba
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+
+	now := time.Now()
+	s := NewMemoryStore(2)
+	s.now = func() time.Time { return now }
+
+	require.NoError(t, s.Put(1, []byte("a"), time.Minute))
+	require.NoError(t, s.Put(2, []byte("b"), time.Second))
+
+	// 1 is used, thus 2 is evicted
+	v, err := s.Get(1)
+	require.NoError(t, err)
+	require.Equal(t, []byte("a"), v)
+	require.NoError(t, s.Put(3, []byte("c"), time.Minute))
+	require.Equal(t, 2, s.Len())
+
+	_, err = s.Get(2)
+	require.Equal(t, ErrExpired, err)
+
+	now = now.Add(time.Minute)
+	_, err = s.Get(1)
+	require.Equal(t, ErrExpired, err)
+	require.Equal(t, 1, s.Len())
+}
+
+func TestFileStore(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "ikba")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	now := time.Now()
+	s, err := NewFileStore(dir)
+	require.NoError(t, err)
+	s.now = func() time.Time { return now }
+
+	require.NoError(t, s.Put(1, []byte("a"), time.Minute))
+	require.NoError(t, s.Put(2, []byte("b"), time.Hour))
+
+	v, err := s.Get(1)
+	require.NoError(t, err)
+	require.Equal(t, []byte("a"), v)
+
+	_, err = s.Get(3)
+	require.Equal(t, ErrExpired, err)
+
+	now = now.Add(time.Minute)
+	_, err = s.Get(1)
+	require.Equal(t, ErrExpired, err)
+
+	now = now.Add(time.Hour)
+	require.NoError(t, s.Purge())
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, files)
+}
+
+func TestStoredArgs(t *testing.T) {
+
+	query := strings.Repeat("long search query ", 10)
+
+	// No store, no space
+	d := New()
+	require.Equal(t, cBadIndex, d.PutArgString(query))
+
+	store := NewMemoryStore(10)
+	SetStore(store, time.Minute)
+	defer SetStore(nil, 0)
+
+	require.Equal(t, 0, d.PutArgString(query))
+	require.Equal(t, 1, d.PutArgBytes([]byte(query)))
+	require.Equal(t, 2, d.PutArgString("short"))
+	require.Equal(t, int(cPosArgsContent)+9+9+7, int(d[cPosArgsFree]))
+	require.Equal(t, ArgTypeString, d.ArgType(0))
+	require.Equal(t, ArgTypeBytes, d.ArgType(1))
+
+	decoded, err := Decode(d.CallbackData())
+	require.NoError(t, err)
+
+	v, ok := decoded.GetArgString(0)
+	require.True(t, ok)
+	require.Equal(t, query, v)
+
+	b, ok := decoded.GetArgBytesAt(1)
+	require.True(t, ok)
+	require.Equal(t, []byte(query), b)
+
+	var item struct {
+		Query string `ikba:"0"`
+	}
+	require.NoError(t, Unmarshal(decoded, &item))
+	require.Equal(t, query, item.Query)
+
+	// Expired
+	store.now = func() time.Time { return time.Now().Add(time.Hour) }
+	_, err = Decode(d.CallbackData())
+	require.Equal(t, ErrExpired, err)
+
+	_, ok = decoded.GetArgStringAt(0)
+	require.False(t, ok)
+	require.Contains(t, decoded.String(), "!(stored value is not available)")
+}
+
+// failingStore is a Store that fails to get any value.
+type failingStore struct {
+	*MemoryStore
+	err error
+}
+
+func (s failingStore) Get(key uint64) ([]byte, error) {
+	return nil, s.err
+}
+
+func TestStoredArgsStoreError(t *testing.T) {
+
+	errDisk := errors.New("disk failure")
+	SetStore(failingStore{NewMemoryStore(10), errDisk}, time.Minute)
+	defer SetStore(nil, 0)
+
+	d := New()
+	require.Equal(t, 0, d.PutArgString(strings.Repeat("long search query ", 10)))
+
+	_, err := Decode(d.CallbackData())
+	require.Equal(t, errDisk, err)
+}
+
+func TestUnmarshalEvicted(t *testing.T) {
+
+	SetStore(NewMemoryStore(1), time.Minute)
+	defer SetStore(nil, 0)
+
+	query := strings.Repeat("long search query ", 10)
+
+	d := New()
+	require.Equal(t, 0, d.PutArgString(query))
+	decoded, err := Decode(d.CallbackData())
+	require.NoError(t, err)
+
+	// The second stored value evicts the first one
+	require.Equal(t, 0, New().PutArgString(query))
+
+	var item struct {
+		Query string `ikba:"0"`
+	}
+	err = Unmarshal(decoded, &item)
+	require.Equal(t, &FieldError{Field: "Query", Err: ErrExpired}, err)
+}
@@ -0,0 +1,172 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package tgbotapi
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/qioalice/devola-backend-telegram/api"
+)
+
+// KeyboardRegistry is a registry of active reply keyboards (per chat).
+//
+// Telegram Bot API doesn't allow to figure out whether a text has been
+// typed by user or sent by pressing to the keyboard button.
+// But if we know what keyboard is shown in the chat now, we can compare
+// the received text with this keyboard buttons.
+//
+// So, register each reply keyboard you sent to the chat (Register, Track)
+// and use KeyboardRegistry.MakeEventFromUpdate instead of MakeEventFromUpdate.
+// Then text that is the same as some button of chat's active keyboard will be
+// classified as CTypeKeyboardButton, everything else as CTypeText.
+//
+// Only one keyboard may be active for one chat (as in Telegram).
+// Buttons that request contact or location are not registered,
+// because pressing them sends contact or location, not a text.
+//
+// KeyboardRegistry is safe for concurrent use.
+// Nil KeyboardRegistry is allowed and it means there is no active keyboards
+// (Register, Unregister and Track do nothing then).
+// Zero KeyboardRegistry is an empty registry ready to use.
+//
+// More info: CTypeKeyboardButton, MakeEventFromUpdate.
+type KeyboardRegistry struct {
+	mu    sync.RWMutex
+	chats map[int64]*keyboardButtons
+}
+
+// keyboardButtons is an active keyboard of one chat.
+type keyboardButtons struct {
+
+	// Texts of keyboard buttons with trimmed leading and trailing spaces.
+	texts map[string]struct{}
+
+	// Should keyboard be removed from registry after any button is pressed.
+	oneTime bool
+}
+
+// NewKeyboardRegistry creates a new empty KeyboardRegistry object.
+func NewKeyboardRegistry() *KeyboardRegistry {
+	return &KeyboardRegistry{
+		chats: make(map[int64]*keyboardButtons),
+	}
+}
+
+// Register saves keyboard kb as active keyboard of chat chatID.
+// Previous active keyboard of that chat (if any) is overwritten.
+func (r *KeyboardRegistry) Register(chatID int64, kb api.ReplyKeyboardMarkup) {
+
+	if r == nil {
+		return
+	}
+
+	buttons := &keyboardButtons{
+		texts:   make(map[string]struct{}),
+		oneTime: kb.OneTimeKeyboard,
+	}
+
+	for _, row := range kb.Keyboard {
+		for _, button := range row {
+			if button.RequestContact || button.RequestLocation {
+				continue
+			}
+			buttons.texts[strings.TrimSpace(button.Text)] = struct{}{}
+		}
+	}
+
+	r.mu.Lock()
+	if r.chats == nil {
+		r.chats = make(map[int64]*keyboardButtons)
+	}
+	r.chats[chatID] = buttons
+	r.mu.Unlock()
+}
+
+// Unregister removes active keyboard of chat chatID (if any).
+func (r *KeyboardRegistry) Unregister(chatID int64) {
+
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	delete(r.chats, chatID)
+	r.mu.Unlock()
+}
+
+// Track registers or unregisters active keyboard of chat chatID depending on
+// type of markup, that is a reply markup of message you sent to that chat
+// (for example api.MessageConfig.ReplyMarkup).
+//
+// api.ReplyKeyboardMarkup is registered,
+// api.ReplyKeyboardRemove and api.ReplyKeyboardHide unregister the keyboard,
+// any other markup (including nil) is ignored.
+func (r *KeyboardRegistry) Track(chatID int64, markup interface{}) {
+
+	switch markup := markup.(type) {
+
+	case api.ReplyKeyboardMarkup:
+		r.Register(chatID, markup)
+
+	case *api.ReplyKeyboardMarkup:
+		if markup != nil {
+			r.Register(chatID, *markup)
+		}
+
+	case api.ReplyKeyboardRemove, *api.ReplyKeyboardRemove,
+		api.ReplyKeyboardHide, *api.ReplyKeyboardHide:
+		r.Unregister(chatID)
+	}
+}
+
+// IsButton reports whether text is a text of some button of active keyboard
+// of chat chatID.
+func (r *KeyboardRegistry) IsButton(chatID int64, text string) bool {
+
+	if r == nil {
+		return false
+	}
+
+	r.mu.RLock()
+	buttons := r.chats[chatID]
+	r.mu.RUnlock()
+
+	if buttons == nil {
+		return false
+	}
+
+	_, found := buttons.texts[strings.TrimSpace(text)]
+	return found
+}
+
+// MakeEventFromUpdate is the same as package's MakeEventFromUpdate,
+// but CTypeText event will be CTypeKeyboardButton if its text is a text of
+// some button of active keyboard of chat update is received from.
+//
+// One time keyboard is unregistered after its button is detected.
+func (r *KeyboardRegistry) MakeEventFromUpdate(update api.Update) *Event {
+
+	e := MakeEventFromUpdate(update)
+	if r == nil || e.Type != CTypeText || e.msg == nil || e.msg.Chat == nil {
+		return e
+	}
+
+	chatID := e.msg.Chat.ID
+	if !r.IsButton(chatID, string(e.Data)) {
+		return e
+	}
+
+	e.Type = CTypeKeyboardButton
+
+	r.mu.Lock()
+	if buttons := r.chats[chatID]; buttons != nil && buttons.oneTime {
+		delete(r.chats, chatID)
+	}
+	r.mu.Unlock()
+
+	return e
+}
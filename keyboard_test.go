@@ -0,0 +1,79 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package tgbotapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/qioalice/devola-backend-telegram/api"
+)
+
+// newTextUpdate returns an update with text message from chat chatID.
+func newTextUpdate(chatID int64, text string) api.Update {
+	return api.Update{Message: &api.Message{Text: text, Chat: &api.Chat{ID: chatID}}}
+}
+
+func TestKeyboardRegistry(t *testing.T) {
+
+	r := NewKeyboardRegistry()
+	r.Track(1, api.NewReplyKeyboard(
+		api.NewKeyboardButtonRow(
+			api.NewKeyboardButton("Yes"),
+			api.NewKeyboardButton("No"),
+			api.NewKeyboardButtonContact("Share"),
+		),
+	))
+
+	e := r.MakeEventFromUpdate(newTextUpdate(1, " Yes "))
+	require.Equal(t, CTypeKeyboardButton, e.Type)
+	require.EqualValues(t, "Yes", e.Data)
+
+	e = r.MakeEventFromUpdate(newTextUpdate(1, "Share"))
+	require.Equal(t, CTypeText, e.Type)
+
+	e = r.MakeEventFromUpdate(newTextUpdate(2, "Yes"))
+	require.Equal(t, CTypeText, e.Type)
+
+	r.Track(1, api.NewRemoveKeyboard(false))
+	e = r.MakeEventFromUpdate(newTextUpdate(1, "Yes"))
+	require.Equal(t, CTypeText, e.Type)
+}
+
+func TestKeyboardRegistryOneTime(t *testing.T) {
+
+	kb := api.NewReplyKeyboard(api.NewKeyboardButtonRow(api.NewKeyboardButton("Ok")))
+	kb.OneTimeKeyboard = true
+
+	r := NewKeyboardRegistry()
+	r.Register(1, kb)
+
+	require.Equal(t, CTypeKeyboardButton, r.MakeEventFromUpdate(newTextUpdate(1, "Ok")).Type)
+	require.Equal(t, CTypeText, r.MakeEventFromUpdate(newTextUpdate(1, "Ok")).Type)
+
+	var nilRegistry *KeyboardRegistry
+	require.Equal(t, CTypeText, nilRegistry.MakeEventFromUpdate(newTextUpdate(1, "Ok")).Type)
+}
+
+func TestKeyboardRegistryZero(t *testing.T) {
+
+	kb := api.NewReplyKeyboard(api.NewKeyboardButtonRow(api.NewKeyboardButton("Ok")))
+
+	var nilRegistry *KeyboardRegistry
+	require.NotPanics(t, func() {
+		nilRegistry.Register(1, kb)
+		nilRegistry.Track(1, api.NewRemoveKeyboard(false))
+		nilRegistry.Unregister(1)
+	})
+	require.False(t, nilRegistry.IsButton(1, "Ok"))
+
+	var r KeyboardRegistry
+	r.Unregister(1)
+	r.Track(1, kb)
+	require.True(t, r.IsButton(1, "Ok"))
+	require.Equal(t, CTypeKeyboardButton, r.MakeEventFromUpdate(newTextUpdate(1, "Ok")).Type)
+}
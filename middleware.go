@@ -0,0 +1,59 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package tgbotapi
+
+import (
+	"runtime/debug"
+
+	"github.com/qioalice/devola-backend-telegram/api"
+)
+
+// Recovery returns a Middleware that recovers from panic of next handlers
+// and logs it using logger with the stack trace.
+// The update that caused panic is considered handled.
+func Recovery(logger api.BotLogger) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx *Ctx) {
+			defer func() {
+				if err := recover(); err != nil {
+					logger.Printf("Update %d (event type %d) handler panics: %v\n%s",
+						ctx.Update.UpdateID, ctx.Event.Type, err, debug.Stack())
+				}
+			}()
+			next(ctx)
+		}
+	}
+}
+
+// Logging returns a Middleware that logs each update's ID,
+// event type and event data using logger before calling next handlers.
+func Logging(logger api.BotLogger) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx *Ctx) {
+			logger.Printf("Update %d: event type %d, data %q",
+				ctx.Update.UpdateID, ctx.Event.Type, ctx.Event.Data)
+			next(ctx)
+		}
+	}
+}
+
+// Auth returns a Middleware that calls next handlers only if allow returns
+// true for the update context. Otherwise update is ignored
+// (or handled by denied handler if it's not nil).
+func Auth(allow func(ctx *Ctx) bool, denied Handler) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx *Ctx) {
+			switch {
+
+			case allow(ctx):
+				next(ctx)
+
+			case denied != nil:
+				denied(ctx)
+			}
+		}
+	}
+}
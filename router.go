@@ -0,0 +1,367 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package tgbotapi
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/qioalice/devola/core/chat"
+	"github.com/qioalice/devola/core/event"
+	"github.com/qioalice/devola/core/view"
+
+	"github.com/qioalice/devola-backend-telegram/api"
+)
+
+// Handler is a function that handles one classified Telegram Bot API update.
+// ctx contains everything about update (see Ctx).
+type Handler func(ctx *Ctx)
+
+// Middleware is a function that wraps a Handler and returns a new Handler.
+// Middleware may do something before and/or after calling next,
+// or not call next at all (for example if user is not authorized).
+type Middleware func(next Handler) Handler
+
+// Router is a dispatcher of Telegram Bot API updates.
+//
+// Router classifies each update (see MakeEventFromUpdate) and calls
+// the handler registered for the event's type and data.
+// Handlers are checked in order of registration, the first matched is called.
+// If no one handler is matched, the fallback handler is called (if any).
+//
+// The handler is called through the middleware chain (see Use).
+// Middleware registered first is the outermost one.
+//
+// Register all handlers and middleware before Serve or Dispatch is called.
+//
+// More info: Handler, Middleware, Event.
+type Router struct {
+	bot *api.BotAPI
+
+	// Registry of active reply keyboards.
+	// If it's not nil, it's used to detect CTypeKeyboardButton events.
+	keyboards *KeyboardRegistry
+
+	// Registry of views.
+	// If it's not nil, pressed inline keyboard buttons are routed to
+	// the handler of view which ID is encoded into the button.
+	views *ViewRegistry
+
+	// Function that returns the current session ID of chat.
+	// If it's not nil, pressed inline keyboard buttons linked with
+	// not current session are stale and handled by stale handler.
+	sessions SessionFunc
+	stale    Handler
+
+	// Handler of pressed inline keyboard buttons which encoded IKB action
+	// has expired (CTypeExpiredInlineKeyboardButton events).
+	expired Handler
+
+	// Handler of pressed inline keyboard buttons with malformed, foreign
+	// or forged callback data (CTypeInvalidInlineKeyboardButton events).
+	invalid Handler
+
+	routes      []route
+	middlewares []Middleware
+	fallback    Handler
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// SessionFunc is a function that returns the current session ID
+// of chat chatID.
+type SessionFunc func(chatID int64) chat.SessionID
+
+// route is one registered handler with the rule of event matching.
+type route struct {
+	typ   event.Type
+	match func(e *Event) bool
+	h     Handler
+}
+
+// NewRouter creates a new Router object that will dispatch updates received
+// by bot.
+func NewRouter(bot *api.BotAPI) *Router {
+	return &Router{
+		bot:     bot,
+		stale:   StaleAnswer(DefaultStaleText, false),
+		expired: StaleAnswer(DefaultExpiredText, false),
+		invalid: StaleAnswer(DefaultInvalidText, false),
+		stop:    make(chan struct{}),
+	}
+}
+
+// WithKeyboards sets the registry of active reply keyboards that will be used
+// to detect CTypeKeyboardButton events and returns r.
+func (r *Router) WithKeyboards(keyboards *KeyboardRegistry) *Router {
+	r.keyboards = keyboards
+	return r
+}
+
+// WithSessions sets the function that returns the current session ID of chat
+// and returns r.
+//
+// Then each pressed inline keyboard button which encoded session ID
+// is not chat.CSessionIDNil and is not the current session ID of its chat
+// is stale. Stale buttons are handled by stale handler (see OnStale)
+// and registered handlers are never called for them.
+//
+// Also Ctx.SessionID of any update that has a chat will be the current
+// session ID of that chat.
+func (r *Router) WithSessions(sessions SessionFunc) *Router {
+	r.sessions = sessions
+	return r
+}
+
+// OnStale sets handler h that will be called for stale pressed inline keyboard
+// buttons (see WithSessions) instead of registered handlers.
+// Like registered handlers, it's called through the middleware chain
+// (see Use), but Ctx.SessionID is the session ID encoded into the button.
+// By default it's StaleAnswer(DefaultStaleText, false).
+func (r *Router) OnStale(h Handler) *Router {
+	r.stale = h
+	return r
+}
+
+// OnExpired sets handler h that will be called for pressed inline keyboard
+// buttons which encoded IKB action has expired (see ikba.Encoded.SetExpiry)
+// instead of registered handlers. Like registered handlers, it's called
+// through the middleware chain (see Use).
+// By default it's StaleAnswer(DefaultExpiredText, false).
+//
+// If h is nil, CTypeExpiredInlineKeyboardButton events are routed
+// as any other events.
+func (r *Router) OnExpired(h Handler) *Router {
+	r.expired = h
+	return r
+}
+
+// OnInvalid sets handler h that will be called for pressed inline keyboard
+// buttons with malformed, foreign or forged callback data
+// (CTypeInvalidInlineKeyboardButton events) through the middleware chain
+// (see Use). Such buttons never reach registered handlers
+// (and fallback handler), if h is nil they are ignored.
+// By default it's StaleAnswer(DefaultInvalidText, false).
+func (r *Router) OnInvalid(h Handler) *Router {
+	r.invalid = h
+	return r
+}
+
+// DefaultStaleText is the default text of notification shown to user
+// when stale inline keyboard button is pressed.
+const DefaultStaleText = "This menu is outdated"
+
+// DefaultExpiredText is the default text of notification shown to user
+// when inline keyboard button which encoded IKB action has expired is pressed.
+const DefaultExpiredText = "This button has expired"
+
+// DefaultInvalidText is the default text of notification shown to user
+// when inline keyboard button with invalid callback data is pressed.
+const DefaultInvalidText = "This button is not valid"
+
+// StaleAnswer returns a Handler for stale pressed inline keyboard buttons
+// (see Router.OnStale) that answers to the callback with notification text
+// and removes the inline keyboard of outdated message if removeKeyboard is true.
+//
+// Errors are ignored, because the stale button is not user's fault.
+func StaleAnswer(text string, removeKeyboard bool) Handler {
+	return func(ctx *Ctx) {
+		_ = ctx.AnswerCallback(text, false)
+		if removeKeyboard {
+			_ = ctx.RemoveKeyboard()
+		}
+	}
+}
+
+// WithViews sets the registry of views and returns r.
+//
+// Then pressed inline keyboard button which is not matched by any
+// registered handler is routed to the handler of view which ID
+// is encoded into the button (if this view is registered and has handler).
+func (r *Router) WithViews(views *ViewRegistry) *Router {
+	r.views = views
+	return r
+}
+
+// Keyboards returns the registry of active reply keyboards (may be nil).
+func (r *Router) Keyboards() *KeyboardRegistry {
+	return r.keyboards
+}
+
+// handle registers handler h for events with type typ that satisfy match.
+// Nil match means any event's data.
+func (r *Router) handle(typ event.Type, match func(e *Event) bool, h Handler) *Router {
+	r.routes = append(r.routes, route{typ: typ, match: match, h: h})
+	return r
+}
+
+// On registers handler h for events with type typ and any data.
+func (r *Router) On(typ event.Type, h Handler) *Router {
+	return r.handle(typ, nil, h)
+}
+
+// OnData registers handler h for events with type typ and exactly
+// the same data as data.
+func (r *Router) OnData(typ event.Type, data event.Data, h Handler) *Router {
+	return r.handle(typ, func(e *Event) bool {
+		return e.Data == data
+	}, h)
+}
+
+// OnPrefix registers handler h for events with type typ and data that
+// starts with prefix.
+func (r *Router) OnPrefix(typ event.Type, prefix string, h Handler) *Router {
+	return r.handle(typ, func(e *Event) bool {
+		return strings.HasPrefix(string(e.Data), prefix)
+	}, h)
+}
+
+// OnRegexp registers handler h for events with type typ and data that
+// matches re.
+func (r *Router) OnRegexp(typ event.Type, re *regexp.Regexp, h Handler) *Router {
+	return r.handle(typ, func(e *Event) bool {
+		return re.MatchString(string(e.Data))
+	}, h)
+}
+
+// OnCommand registers handler h for command cmd (without leading slash).
+// Command is case insensitive.
+func (r *Router) OnCommand(cmd string, h Handler) *Router {
+	return r.OnData(CTypeCommand, event.Data(strings.ToLower(cmd)), h)
+}
+
+// OnView registers handler h for pressed inline keyboard buttons
+// which encoded IKB action has view ID id.
+func (r *Router) OnView(id view.IDEnc, h Handler) *Router {
+	return r.handle(CTypeInlineKeyboardButton, func(e *Event) bool {
+		return e.ikbae != nil && e.ikbae.GetViewID() == id
+	}, h)
+}
+
+// Fallback sets handler h that will be called if no one registered handler
+// matches the event.
+func (r *Router) Fallback(h Handler) *Router {
+	r.fallback = h
+	return r
+}
+
+// Use appends middleware to the middleware chain.
+// Each handler is called through it, including stale, expired and invalid
+// handlers (see OnStale, OnExpired, OnInvalid).
+func (r *Router) Use(middleware ...Middleware) *Router {
+	r.middlewares = append(r.middlewares, middleware...)
+	return r
+}
+
+// lookup returns the handler that should handle event e.
+// Registered handlers are checked first, then the handler of view
+// (for pressed inline keyboard buttons).
+// If no one handler is matched, fallback handler is returned (may be nil).
+func (r *Router) lookup(e *Event) Handler {
+
+	for i := range r.routes {
+		if r.routes[i].typ == e.Type &&
+			(r.routes[i].match == nil || r.routes[i].match(e)) {
+			return r.routes[i].h
+		}
+	}
+
+	if e.ikbae != nil {
+		if v := r.views.Lookup(e.ikbae.GetViewID()); v != nil && v.handle != nil {
+			return v.handle
+		}
+	}
+
+	return r.fallback
+}
+
+// Dispatch classifies update and calls the handler that should handle it
+// through the middleware chain.
+//
+// If there is no handler for update (and fallback handler is not set),
+// update is ignored.
+func (r *Router) Dispatch(update api.Update) {
+
+	e := r.keyboards.MakeEventFromUpdate(update)
+	ctx := newCtx(r.bot, update, e, r.keyboards)
+
+	if e.Type == CTypeExpiredInlineKeyboardButton && r.expired != nil {
+		r.call(r.expired, ctx)
+		return
+	}
+
+	// Tampered or foreign callback data never reaches registered handlers
+	if e.Type == CTypeInvalidInlineKeyboardButton {
+		if r.invalid != nil {
+			r.call(r.invalid, ctx)
+		}
+		return
+	}
+
+	if r.sessions != nil && ctx.Chat != nil {
+		current := r.sessions(ctx.Chat.ID)
+
+		if ctx.IKBA != nil && ctx.SessionID != chat.CSessionIDNil &&
+			ctx.SessionID != current {
+			if r.stale != nil {
+				r.call(r.stale, ctx)
+			}
+			return
+		}
+
+		ctx.SessionID = current
+	}
+
+	if h := r.lookup(e); h != nil {
+		r.call(h, ctx)
+	}
+}
+
+// call calls handler h with ctx through the middleware chain.
+func (r *Router) call(h Handler, ctx *Ctx) {
+
+	for i := len(r.middlewares) - 1; i >= 0; i-- {
+		h = r.middlewares[i](h)
+	}
+
+	h(ctx)
+}
+
+// Serve dispatches each update from updates (see Dispatch) in the current
+// goroutine, one by one, until updates is closed or Stop is called.
+//
+// Usually updates is a channel returned by api.BotAPI.GetUpdatesChan.
+func (r *Router) Serve(updates api.UpdatesChannel) {
+
+	for {
+		select {
+
+		case <-r.stop:
+			return
+
+		case update, ok := <-updates:
+			if !ok {
+				return
+			}
+			r.Dispatch(update)
+		}
+	}
+}
+
+// ServeBot is the same as Serve, but updates are received from
+// router's BotAPI object (api.BotAPI.GetUpdatesChan).
+func (r *Router) ServeBot() {
+	r.Serve(r.bot.GetUpdatesChan())
+}
+
+// Stop stops Serve. Update that is handling now will be handled completely.
+func (r *Router) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.stop)
+	})
+}
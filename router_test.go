@@ -0,0 +1,202 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package tgbotapi
+
+import (
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/qioalice/devola/core/chat"
+	"github.com/qioalice/devola/core/view"
+
+	"github.com/qioalice/devola-backend-telegram/api"
+	"github.com/qioalice/devola-backend-telegram/ikba"
+)
+
+// recorder returns a Handler that appends name to the calls.
+func recorder(calls *[]string, name string) Handler {
+	return func(*Ctx) {
+		*calls = append(*calls, name)
+	}
+}
+
+func TestRouterDispatch(t *testing.T) {
+
+	var calls []string
+
+	r := NewRouter(nil).
+		OnCommand("Start", recorder(&calls, "start")).
+		OnPrefix(CTypeText, "buy ", recorder(&calls, "buy")).
+		OnRegexp(CTypeText, regexp.MustCompile(`^\d+$`), recorder(&calls, "number")).
+		OnView(view.IDEnc(5), recorder(&calls, "view")).
+		On(CTypeText, recorder(&calls, "text")).
+		Fallback(recorder(&calls, "fallback"))
+
+	ikbae := ikba.New()
+	ikbae.PutViewID(view.IDEnc(5))
+
+	r.Dispatch(newCommandUpdate("/start", 6))
+	r.Dispatch(newTextUpdate(1, "buy apple"))
+	r.Dispatch(newTextUpdate(1, "42"))
+	r.Dispatch(newTextUpdate(1, "hello"))
+	r.Dispatch(api.Update{CallbackQuery: &api.CallbackQuery{Data: ikbae.CallbackData()}})
+	r.Dispatch(api.Update{})
+
+	require.Equal(t,
+		[]string{"start", "buy", "number", "text", "view", "fallback"}, calls)
+}
+
+func TestRouterMiddleware(t *testing.T) {
+
+	var calls []string
+
+	mw := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx *Ctx) {
+				calls = append(calls, name)
+				next(ctx)
+			}
+		}
+	}
+
+	deny := Auth(func(ctx *Ctx) bool {
+		return ctx.Event.Data != "secret"
+	}, recorder(&calls, "denied"))
+
+	r := NewRouter(nil).
+		Use(mw("first"), mw("second"), deny).
+		On(CTypeText, recorder(&calls, "text"))
+
+	r.Dispatch(newTextUpdate(1, "hello"))
+	r.Dispatch(newTextUpdate(1, "secret"))
+
+	require.Equal(t,
+		[]string{"first", "second", "text", "first", "second", "denied"}, calls)
+}
+
+func TestRouterServe(t *testing.T) {
+
+	var calls []string
+
+	r := NewRouter(nil).On(CTypeText, recorder(&calls, "text"))
+
+	updates := make(chan api.Update, 2)
+	updates <- newTextUpdate(1, "a")
+	updates <- newTextUpdate(1, "b")
+	close(updates)
+
+	r.Serve(updates)
+	require.Equal(t, []string{"text", "text"}, calls)
+}
+
+func TestRouterStale(t *testing.T) {
+
+	var calls []string
+
+	r := NewRouter(nil).
+		WithSessions(func(chatID int64) chat.SessionID {
+			return chat.SessionID(chatID * 10)
+		}).
+		OnStale(recorder(&calls, "stale")).
+		Use(func(next Handler) Handler {
+			return func(ctx *Ctx) {
+				calls = append(calls, "session "+strconv.Itoa(int(ctx.SessionID)))
+				next(ctx)
+			}
+		}).
+		On(CTypeInlineKeyboardButton, recorder(&calls, "ikb")).
+		On(CTypeText, recorder(&calls, "text"))
+
+	press := func(ssid chat.SessionID) api.Update {
+		ikbae := ikba.New()
+		ikbae.PutSessionID(ssid)
+		return api.Update{CallbackQuery: &api.CallbackQuery{
+			Message: &api.Message{Chat: &api.Chat{ID: 2}},
+			Data:    ikbae.CallbackData(),
+		}}
+	}
+
+	r.Dispatch(press(chat.SessionID(20)))
+	r.Dispatch(press(chat.SessionID(19)))
+	r.Dispatch(press(chat.CSessionIDNil))
+	r.Dispatch(newTextUpdate(3, "hello"))
+
+	require.Equal(t, []string{
+		"session 20", "ikb",
+		"session 19", "stale",
+		"session 20", "ikb",
+		"session 30", "text",
+	}, calls)
+}
+
+func TestRouterInvalid(t *testing.T) {
+
+	var calls []string
+
+	r := NewRouter(nil).
+		OnInvalid(recorder(&calls, "invalid")).
+		Use(func(next Handler) Handler {
+			return func(ctx *Ctx) {
+				calls = append(calls, "middleware")
+				next(ctx)
+			}
+		}).
+		On(CTypeInvalidInlineKeyboardButton, recorder(&calls, "routed")).
+		Fallback(recorder(&calls, "fallback"))
+
+	press := api.Update{CallbackQuery: &api.CallbackQuery{
+		Message: &api.Message{Chat: &api.Chat{ID: 2}},
+		Data:    "forged",
+	}}
+
+	r.Dispatch(press)
+
+	r.OnInvalid(nil)
+	r.Dispatch(press)
+
+	require.Equal(t, []string{"middleware", "invalid"}, calls)
+}
+
+func TestRouterExpired(t *testing.T) {
+
+	var calls []string
+
+	r := NewRouter(nil).
+		OnExpired(recorder(&calls, "expired")).
+		Use(func(next Handler) Handler {
+			return func(ctx *Ctx) {
+				calls = append(calls, "middleware")
+				next(ctx)
+			}
+		}).
+		On(CTypeInlineKeyboardButton, recorder(&calls, "ikb")).
+		On(CTypeExpiredInlineKeyboardButton, recorder(&calls, "routed"))
+
+	press := func(deadline time.Time) api.Update {
+		ikbae := ikba.New()
+		ikbae.SetExpiry(deadline)
+		return api.Update{CallbackQuery: &api.CallbackQuery{
+			Message: &api.Message{Chat: &api.Chat{ID: 2}},
+			Data:    ikbae.CallbackData(),
+		}}
+	}
+
+	r.Dispatch(press(time.Now().Add(time.Hour)))
+	r.Dispatch(press(time.Now().Add(-time.Hour)))
+
+	r.OnExpired(nil)
+	r.Dispatch(press(time.Now().Add(-time.Hour)))
+
+	require.Equal(t, []string{
+		"middleware", "ikb",
+		"middleware", "expired",
+		"middleware", "routed",
+	}, calls)
+}
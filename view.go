@@ -0,0 +1,187 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package tgbotapi
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+
+	"github.com/qioalice/devola/core/chat"
+	"github.com/qioalice/devola/core/view"
+
+	"github.com/qioalice/devola-backend-telegram/api"
+	"github.com/qioalice/devola-backend-telegram/ikba"
+)
+
+// RenderFunc is a function that builds the text and the inline keyboard
+// of some view for the update context ctx.
+type RenderFunc func(ctx *Ctx) (text string, markup api.InlineKeyboardMarkup, err error)
+
+// View is a named screen of bot: a message with inline keyboard
+// and a handler of that keyboard buttons.
+//
+// Each view has a stable encoded view ID (view.IDEnc) that is generated
+// from the view's name. Encode it to each inline keyboard button of view
+// (NewAction does it), and then the pressed button will be routed
+// to the view's handler automatically (see Router.WithViews).
+//
+// More info: ViewRegistry, RenderFunc, Handler, ikba.Encoded.
+type View struct {
+	name   string
+	id     view.IDEnc
+	render RenderFunc
+	handle Handler
+}
+
+// Name returns the name of view v.
+func (v *View) Name() string {
+	return v.name
+}
+
+// ID returns the encoded view ID of view v.
+func (v *View) ID() view.IDEnc {
+	return v.id
+}
+
+// NewAction creates a new encoded IKB action with view ID of view v and
+// session ID ssid. Put arguments to it and use it as button's callback data.
+func (v *View) NewAction(ssid chat.SessionID) *ikba.Encoded {
+	ikbae := ikba.New()
+	ikbae.PutViewID(v.id)
+	ikbae.PutSessionID(ssid)
+	return ikbae
+}
+
+// Render builds the text and the inline keyboard of view v
+// for the update context ctx.
+func (v *View) Render(ctx *Ctx) (text string, markup api.InlineKeyboardMarkup, err error) {
+	return v.render(ctx)
+}
+
+// Show renders view v and sends it as a new message to the chat
+// update has been received from.
+func (v *View) Show(ctx *Ctx) (*api.Message, error) {
+
+	text, markup, err := v.render(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ctx.Reply(text, markup)
+}
+
+// Refresh renders view v and replaces the message which inline keyboard button
+// has been pressed by it.
+func (v *View) Refresh(ctx *Ctx) (*api.Message, error) {
+
+	text, markup, err := v.render(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ctx.Edit(text, &markup)
+}
+
+// ErrViewName means that view can't be registered, because its name is empty.
+var ErrViewName = errors.New("tgbotapi: view name is empty")
+
+// ViewRegistry is a registry of views.
+//
+// Register all views at startup. Register returns an error if view's name
+// is already registered or if view's ID (generated from the name) collides
+// with the ID of already registered view.
+//
+// ViewRegistry is safe for concurrent use.
+//
+// More info: View, Router.WithViews.
+type ViewRegistry struct {
+	mu     sync.RWMutex
+	byID   map[view.IDEnc]*View
+	byName map[string]*View
+}
+
+// NewViewRegistry creates a new empty ViewRegistry object.
+func NewViewRegistry() *ViewRegistry {
+	return &ViewRegistry{
+		byID:   make(map[view.IDEnc]*View),
+		byName: make(map[string]*View),
+	}
+}
+
+// viewID generates the stable encoded view ID from the view's name.
+func viewID(name string) view.IDEnc {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	return view.IDEnc(int32(h.Sum32()))
+}
+
+// Register registers a new view with name, render function and
+// handler of its inline keyboard buttons, and returns it.
+//
+// Nil and error is returned if name is empty or already registered,
+// or if its generated ID collides with the ID of already registered view
+// (rename one of them in that case).
+func (r *ViewRegistry) Register(name string, render RenderFunc, handle Handler) (*View, error) {
+
+	if name == "" {
+		return nil, ErrViewName
+	}
+
+	v := &View{name: name, id: viewID(name), render: render, handle: handle}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, found := r.byName[name]; found {
+		return nil, fmt.Errorf("tgbotapi: view %q is already registered", name)
+	}
+
+	if v.id == view.CIDEncNil {
+		return nil, fmt.Errorf("tgbotapi: view %q has nil ID", name)
+	}
+
+	if another, found := r.byID[v.id]; found {
+		return nil, fmt.Errorf("tgbotapi: view %q ID collides with view %q ID",
+			name, another.name)
+	}
+
+	r.byID[v.id], r.byName[name] = v, v
+	return v, nil
+}
+
+// MustRegister is the same as Register but panics if error occurred.
+func (r *ViewRegistry) MustRegister(name string, render RenderFunc, handle Handler) *View {
+
+	v, err := r.Register(name, render, handle)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// Lookup returns the view with encoded view ID id or nil if there is no one.
+func (r *ViewRegistry) Lookup(id view.IDEnc) *View {
+
+	if r == nil {
+		return nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.byID[id]
+}
+
+// ByName returns the view with name or nil if there is no one.
+func (r *ViewRegistry) ByName(name string) *View {
+
+	if r == nil {
+		return nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.byName[name]
+}
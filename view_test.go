@@ -0,0 +1,59 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package tgbotapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/qioalice/devola/core/chat"
+
+	"github.com/qioalice/devola-backend-telegram/api"
+)
+
+func TestViewRegistry(t *testing.T) {
+
+	var calls []string
+
+	views := NewViewRegistry()
+	menu := views.MustRegister("menu", nil, recorder(&calls, "menu"))
+	views.MustRegister("settings", nil, recorder(&calls, "settings"))
+
+	require.Equal(t, viewID("menu"), menu.ID())
+	require.True(t, menu == views.Lookup(menu.ID()))
+	require.True(t, menu == views.ByName("menu"))
+
+	_, err := views.Register("menu", nil, nil)
+	require.Error(t, err)
+
+	_, err = views.Register("", nil, nil)
+	require.Equal(t, ErrViewName, err)
+
+	r := NewRouter(nil).WithViews(views).Fallback(recorder(&calls, "fallback"))
+
+	press := func(v *View) api.Update {
+		return api.Update{CallbackQuery: &api.CallbackQuery{
+			Data: v.NewAction(chat.CSessionIDNil).CallbackData(),
+		}}
+	}
+
+	r.Dispatch(press(views.ByName("settings")))
+	r.Dispatch(press(menu))
+	r.Dispatch(press(&View{id: viewID("unknown")}))
+
+	require.Equal(t, []string{"settings", "menu", "fallback"}, calls)
+}
+
+func TestViewIDCollision(t *testing.T) {
+
+	// "costarring" and "liquid" are known FNV-1a 32 collision
+	views := NewViewRegistry()
+	views.MustRegister("costarring", nil, nil)
+
+	_, err := views.Register("liquid", nil, nil)
+	require.Error(t, err)
+}